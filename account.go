@@ -0,0 +1,277 @@
+package rego
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Prefs represents the preferences of the logged-in account. Only commonly
+// used preferences are decoded, any preference may be changed using
+// Session.UpdatePrefs.
+type Prefs struct {
+	AcceptPMs            string                     `json:"accept_pms"`             // "everyone" or "whitelisted"
+	AllowClickTracking   bool                       `json:"allow_clicktracking"`    //
+	Beta                 bool                       `json:"beta"`                   // Opted in to beta testing
+	ClickGadget          bool                       `json:"clickgadget"`            // Show recently viewed links
+	CollapseReadMessages bool                       `json:"collapse_read_messages"` //
+	Compress             bool                       `json:"compress"`               // Compress the link display
+	CountryCode          string                     `json:"country_code"`           //
+	DefaultCommentSort   string                     `json:"default_comment_sort"`   // e.g. "confidence", "new", "top"
+	EmailMessages        bool                       `json:"email_messages"`         // Send messages as emails
+	EnableFollowers      bool                       `json:"enable_followers"`       //
+	Extra                map[string]json.RawMessage `json:"-"`                      // Fields not decoded into the struct, kept when encoding
+	HideDowns            bool                       `json:"hide_downs"`             // Hide links the user has downvoted
+	HideFromRobots       bool                       `json:"hide_from_robots"`       // Hide the profile from search engines
+	HideUps              bool                       `json:"hide_ups"`               // Hide links the user has upvoted
+	IgnoreSuggestedSort  bool                       `json:"ignore_suggested_sort"`  //
+	LabelNSFW            bool                       `json:"label_nsfw"`             // Label NSFW links
+	Lang                 string                     `json:"lang"`                   // Interface language, e.g. "en"
+	MarkMessagesRead     bool                       `json:"mark_messages_read"`     // Mark messages read when opening the inbox
+	Media                string                     `json:"media"`                  // Thumbnails, "on", "off" or "subreddit"
+	MediaPreview         string                     `json:"media_preview"`          // Media previews, "on", "off" or "subreddit"
+	MinCommentScore      *int                       `json:"min_comment_score"`      // Hide comments scoring below, nil if unset
+	MinLinkScore         *int                       `json:"min_link_score"`         // Hide links scoring below, nil if unset
+	NightMode            bool                       `json:"nightmode"`              //
+	NumComments          int                        `json:"num_comments"`           // Default number of comments displayed
+	NumSites             int                        `json:"numsites"`               // Number of links displayed at once
+	Over18               bool                       `json:"over_18"`                // User is over 18 and wants NSFW content
+	PrivateFeeds         bool                       `json:"private_feeds"`          //
+	ProfileOptOut        bool                       `json:"profile_opt_out"`        //
+	PublicVotes          bool                       `json:"public_votes"`           // Make votes public
+	SearchIncludeOver18  bool                       `json:"search_include_over_18"` //
+	ShowFlair            bool                       `json:"show_flair"`             // Show user flair
+	ShowLinkFlair        bool                       `json:"show_link_flair"`        // Show link flair
+	ShowPresence         bool                       `json:"show_presence"`          //
+	ShowTrending         bool                       `json:"show_trending"`          //
+	StoreVisits          bool                       `json:"store_visits"`           //
+	ThreadedMessages     bool                       `json:"threaded_messages"`      //
+	TopKarmaSubreddits   bool                       `json:"top_karma_subreddits"`   //
+}
+
+// UnmarshalJSON decodes the account preferences, unknown fields are kept in Extra
+func (p *Prefs) UnmarshalJSON(b []byte) error {
+	type prefs Prefs
+	extra, err := unmarshalExtra(b, (*prefs)(p))
+	if err != nil {
+		return err
+	}
+	p.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the preferences as sent by Reddit, including Extra fields
+func (p Prefs) MarshalJSON() ([]byte, error) {
+	type prefs Prefs
+	return marshalExtra((*prefs)(&p), p.Extra)
+}
+
+// KarmaBreakdown is the karma of the logged-in account in a single subreddit
+type KarmaBreakdown struct {
+	CommentKarma int                        `json:"comment_karma"` // Comment karma in the subreddit
+	Extra        map[string]json.RawMessage `json:"-"`             // Fields not decoded into the struct, kept when encoding
+	LinkKarma    int                        `json:"link_karma"`    // Link karma in the subreddit
+	Subreddit    string                     `json:"sr"`            // Subreddit name
+}
+
+// UnmarshalJSON decodes a karma breakdown entry, unknown fields are kept in Extra
+func (k *KarmaBreakdown) UnmarshalJSON(b []byte) error {
+	type karma KarmaBreakdown
+	extra, err := unmarshalExtra(b, (*karma)(k))
+	if err != nil {
+		return err
+	}
+	k.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the karma breakdown entry as sent by Reddit, including Extra fields
+func (k KarmaBreakdown) MarshalJSON() ([]byte, error) {
+	type karma KarmaBreakdown
+	return marshalExtra((*karma)(&k), k.Extra)
+}
+
+// Trophy represents a trophy awarded to a user
+type Trophy struct {
+	AwardID     string                     `json:"award_id"`    //
+	Description string                     `json:"description"` //
+	Extra       map[string]json.RawMessage `json:"-"`           // Fields not decoded into the struct, kept when encoding
+	GrantedAt   Timestamp                  `json:"granted_at"`  // Time awarded, zero if unknown
+	Icon40      string                     `json:"icon_40"`     // URL of 40x40 icon
+	Icon70      string                     `json:"icon_70"`     // URL of 70x70 icon
+	ID          string                     `json:"id"`          //
+	Name        string                     `json:"name"`        // Trophy name, e.g. "Verified Email"
+	URL         string                     `json:"url"`         //
+}
+
+// UnmarshalJSON decodes a t6 Thing data structure, unknown fields are kept in Extra
+func (t *Trophy) UnmarshalJSON(b []byte) error {
+	type trophy Trophy
+	extra, err := unmarshalExtra(b, (*trophy)(t))
+	if err != nil {
+		return err
+	}
+	t.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the trophy as sent by Reddit, including Extra fields
+func (t Trophy) MarshalJSON() ([]byte, error) {
+	type trophy Trophy
+	return marshalExtra((*trophy)(&t), t.Extra)
+}
+
+// Relationship represents a user on the friend or blocked list of the logged-in account
+type Relationship struct {
+	Date  Timestamp                  `json:"date"`   // Time the relationship was created
+	Extra map[string]json.RawMessage `json:"-"`      // Fields not decoded into the struct, kept when encoding
+	ID    Fullname                   `json:"id"`     // Fullname of the user, e.g. "t2_c3v7f8u"
+	Name  string                     `json:"name"`   // Account name of the user
+	Note  string                     `json:"note"`   // Friend note, requires Reddit gold
+	RelID string                     `json:"rel_id"` // Relationship identifier
+}
+
+// UnmarshalJSON decodes a UserList item, unknown fields are kept in Extra
+func (r *Relationship) UnmarshalJSON(b []byte) error {
+	type relationship Relationship
+	extra, err := unmarshalExtra(b, (*relationship)(r))
+	if err != nil {
+		return err
+	}
+	r.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the user list item as sent by Reddit, including Extra fields
+func (r Relationship) MarshalJSON() ([]byte, error) {
+	type relationship Relationship
+	return marshalExtra((*relationship)(&r), r.Extra)
+}
+
+// Prefs returns the preferences of the logged-in account
+func (s *Session) Prefs() (*Prefs, error) {
+	prefs := Prefs{}
+	err := s.call("GET", s.url(apiPrefs), nil, &prefs)
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// UpdatePrefs changes the preferences of the logged-in account. The patch is
+// keyed by preference name, e.g. {"over_18": true}, and preferences not
+// present are left unchanged. The resulting preferences are returned.
+func (s *Session) UpdatePrefs(patch map[string]interface{}) (*Prefs, error) {
+	prefs := Prefs{}
+	err := s.callJSON("PATCH", s.url(apiPrefs), patch, &prefs)
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// Karma returns the per subreddit karma breakdown of the logged-in account
+func (s *Session) Karma() ([]KarmaBreakdown, error) {
+	var karma []KarmaBreakdown
+	err := s.getThing(s.url(apiKarma), nil, TypeKarmaList, &karma)
+	if err != nil {
+		return nil, err
+	}
+	return karma, nil
+}
+
+// Trophies returns the trophies of user u. An empty user name
+// returns the trophies of the logged-in account.
+func (s *Session) Trophies(u string) ([]Trophy, error) {
+	method := s.url(apiMyTrophies)
+	if len(u) > 0 {
+		method = s.url(fmt.Sprintf(apiTrophies, u))
+	}
+
+	list := struct {
+		Trophies []Thing `json:"trophies"`
+	}{}
+	err := s.getThing(method, nil, TypeTrophyList, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	var trophies []Trophy
+	for _, t := range list.Trophies {
+		if t.Kind != TypeAward {
+			continue
+		}
+		trophy := Trophy{}
+		err = json.Unmarshal(t.Data, &trophy)
+		if err != nil {
+			return nil, err
+		}
+		trophies = append(trophies, trophy)
+	}
+	return trophies, nil
+}
+
+// Friends returns the friend list of the logged-in account
+func (s *Session) Friends() ([]Relationship, error) {
+	return s.userList(s.url(apiFriends))
+}
+
+// AddFriend adds user u to the friend list of the logged-in account.
+// Setting a note requires Reddit gold, leave it empty otherwise.
+func (s *Session) AddFriend(u string, note string) (*Relationship, error) {
+	body := map[string]string{"name": u}
+	if len(note) > 0 {
+		body["note"] = note
+	}
+
+	r := Relationship{}
+	err := s.callJSON("PUT", s.url(fmt.Sprintf(apiFriend, u)), body, &r)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// RemoveFriend removes user u from the friend list of the logged-in account
+func (s *Session) RemoveFriend(u string) error {
+	return s.call("DELETE", s.url(fmt.Sprintf(apiFriend, u)), nil, nil)
+}
+
+// Blocked returns the blocked users of the logged-in account
+func (s *Session) Blocked() ([]Relationship, error) {
+	return s.userList(s.url(apiBlocked))
+}
+
+// Block blocks user u from contacting the logged-in account
+func (s *Session) Block(u string) error {
+	v := url.Values{}
+	v.Set("name", u)
+
+	return s.call("POST", s.url(apiBlockUser), v, nil)
+}
+
+// Unblock removes user u from the blocked users of the logged-in account
+func (s *Session) Unblock(u string) error {
+	acct, err := s.Me()
+	if err != nil {
+		return err
+	}
+
+	v := url.Values{}
+	v.Set("name", u)
+	v.Set("type", "enemy")
+	v.Set("container", acct.Fullname().String())
+
+	return s.call("POST", s.url(apiUnfriend), v, nil)
+}
+
+func (s *Session) userList(u string) ([]Relationship, error) {
+	list := struct {
+		Children []Relationship `json:"children"`
+	}{}
+	err := s.getThing(u, nil, TypeUserList, &list)
+	if err != nil {
+		return nil, err
+	}
+	return list.Children, nil
+}
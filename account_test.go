@@ -0,0 +1,88 @@
+package rego
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUpdatePrefs(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		patch := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&patch)
+		if patch["over_18"] != true {
+			t.Errorf("Got patch %v", patch)
+		}
+		fmt.Fprint(w, `{"over_18": true, "lang": "en", "min_link_score": null, "min_comment_score": -4}`)
+	}))
+	defer ts.Close()
+
+	prefs, err := s.UpdatePrefs(map[string]interface{}{"over_18": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !prefs.Over18 || prefs.Lang != "en" || prefs.MinLinkScore != nil || *prefs.MinCommentScore != -4 {
+		t.Errorf("Got %+v", prefs)
+	}
+}
+
+func TestKarma(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"kind": "KarmaList", "data": [{"sr": "golang", "comment_karma": 10, "link_karma": 2}]}`)
+	}))
+	defer ts.Close()
+
+	karma, err := s.Karma()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(karma) != 1 || karma[0].Subreddit != "golang" || karma[0].CommentKarma != 10 {
+		t.Errorf("Got %+v", karma)
+	}
+}
+
+func TestTrophies(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/user/bot/trophies" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"kind": "TrophyList", "data": {"trophies": [
+			{"kind": "t6", "data": {"name": "Verified Email", "granted_at": null}},
+			{"kind": "t6", "data": {"name": "Five-Year Club", "granted_at": 1420070400}}]}}`)
+	}))
+	defer ts.Close()
+
+	trophies, err := s.Trophies("bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trophies) != 2 || !trophies[0].GrantedAt.IsZero() || !trophies[1].GrantedAt.Equal(time.Unix(1420070400, 0)) {
+		t.Errorf("Got %+v", trophies)
+	}
+}
+
+func TestUnblock(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case apiMe:
+			fmt.Fprint(w, `{"kind": "t2", "data": {"id": "abc", "name": "bot"}}`)
+		case apiUnfriend:
+			if r.FormValue("container") != "t2_abc" || r.FormValue("type") != "enemy" || r.FormValue("name") != "troll" {
+				t.Errorf("Got form %v", r.Form)
+			}
+			fmt.Fprint(w, `{}`)
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	if err := s.Unblock("troll"); err != nil {
+		t.Error(err)
+	}
+}
@@ -6,16 +6,93 @@ import (
 
 // Reddit API methods
 const (
-	apiClear     = "/api/clear_sessions"
-	apiComment   = "/api/comment"
-	apiDelete    = "/api/del"
-	apiListing   = "/%s.json"
-	apiLogin     = "/api/login"
-	apiMe        = "/api/me.json"
-	apiUserAbout = "/user/%s/about.json"
-	apiSubmit    = "/api/submit"
+	apiClear          = "/api/clear_sessions"
+	apiComment        = "/api/comment"
+	apiDelete         = "/api/del"
+	apiInfo           = "/api/info.json"
+	apiListing        = "/%s.json"
+	apiLogin          = "/api/login"
+	apiMe             = "/api/me.json"
+	apiSubredditAbout = "/r/%s/about.json"
+	apiUserAbout      = "/user/%s/about.json"
+	apiSubmit         = "/api/submit"
+
+	// Account
+	apiBlockUser  = "/api/block_user"
+	apiBlocked    = "/prefs/blocked.json"
+	apiFriend     = "/api/v1/me/friends/%s"
+	apiFriends    = "/api/v1/me/friends"
+	apiKarma      = "/api/v1/me/karma"
+	apiMyTrophies = "/api/v1/me/trophies"
+	apiPrefs      = "/api/v1/me/prefs"
+	apiTrophies   = "/api/v1/user/%s/trophies"
+	apiUnfriend   = "/api/unfriend"
+
+	// Flair
+	apiFlair               = "/r/%s/api/flair"
+	apiFlairCSV            = "/r/%s/api/flaircsv"
+	apiFlairDelete         = "/r/%s/api/deleteflair"
+	apiFlairList           = "/r/%s/api/flairlist.json"
+	apiFlairSelect         = "/r/%s/api/selectflair"
+	apiFlairTemplate       = "/r/%s/api/flairtemplate_v2"
+	apiFlairTemplateClear  = "/r/%s/api/clearflairtemplates"
+	apiFlairTemplateDelete = "/r/%s/api/deleteflairtemplate"
+	apiFlairTemplatesLink  = "/r/%s/api/link_flair_v2.json"
+	apiFlairTemplatesUser  = "/r/%s/api/user_flair_v2.json"
+
+	// Live threads
+	apiLiveAbout        = "/live/%s/about.json"
+	apiLiveAcceptInvite = "/api/live/%s/accept_contributor_invite"
+	apiLiveContributors = "/live/%s/contributors.json"
+	apiLiveDeleteUpdate = "/api/live/%s/delete_update"
+	apiLiveInvite       = "/api/live/%s/invite_contributor"
+	apiLiveLeave        = "/api/live/%s/leave_contributor"
+	apiLivePermissions  = "/api/live/%s/set_contributor_permissions"
+	apiLiveRemove       = "/api/live/%s/rm_contributor"
+	apiLiveRevokeInvite = "/api/live/%s/rm_contributor_invite"
+	apiLiveStrikeUpdate = "/api/live/%s/strike_update"
+	apiLiveUpdate       = "/api/live/%s/update"
+	apiLiveUpdates      = "/live/%s.json"
+
+	// Multireddits
+	apiMulti          = "/api/multi/%s"
+	apiMultiCopy      = "/api/multi/copy"
+	apiMultiListing   = "/user/%s/m/%s/%s.json"
+	apiMultiMine      = "/api/multi/mine"
+	apiMultiRename    = "/api/multi/rename"
+	apiMultiSubreddit = "/api/multi/%s/r/%s"
+
+	// Wiki
+	apiWikiEdit         = "/r/%s/api/wiki/edit"
+	apiWikiEditor       = "/r/%s/api/wiki/alloweditor/%s"
+	apiWikiPage         = "/r/%s/wiki/%s.json"
+	apiWikiPages        = "/r/%s/wiki/pages.json"
+	apiWikiRevert       = "/r/%s/api/wiki/revert"
+	apiWikiRevisions    = "/r/%s/wiki/revisions/%s.json"
+	apiWikiRevisionsAll = "/r/%s/wiki/revisions.json"
+	apiWikiSettings     = "/r/%s/wiki/settings/%s.json"
 )
 
+// endpoints lists the API methods in the order paths are matched against
+// them, more specific methods first. The catch-all apiListing is last.
+var endpoints = []string{
+	apiClear, apiComment, apiDelete, apiInfo, apiLogin, apiMe, apiSubmit,
+	apiBlockUser, apiBlocked, apiFriend, apiFriends, apiKarma, apiMyTrophies,
+	apiPrefs, apiTrophies, apiUnfriend,
+	apiFlair, apiFlairCSV, apiFlairDelete, apiFlairList, apiFlairSelect,
+	apiFlairTemplate, apiFlairTemplateClear, apiFlairTemplateDelete,
+	apiFlairTemplatesLink, apiFlairTemplatesUser,
+	apiLiveAbout, apiLiveAcceptInvite, apiLiveContributors, apiLiveDeleteUpdate,
+	apiLiveInvite, apiLiveLeave, apiLivePermissions, apiLiveRemove,
+	apiLiveRevokeInvite, apiLiveStrikeUpdate, apiLiveUpdate, apiLiveUpdates,
+	apiMultiCopy, apiMultiMine, apiMultiRename, apiMultiSubreddit, apiMulti,
+	apiMultiListing,
+	apiWikiEdit, apiWikiEditor, apiWikiPages, apiWikiRevert, apiWikiRevisionsAll,
+	apiWikiRevisions, apiWikiSettings, apiWikiPage,
+	apiSubredditAbout, apiUserAbout,
+	apiListing,
+}
+
 const (
 	strReddit = "www.reddit.com"
 	strCookie = "reddit_session"
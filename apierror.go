@@ -1,36 +1,224 @@
 package rego
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// APIError represents a Reddit API error
+// maxErrorBody is the max number of reply body bytes kept by an APIError
+const maxErrorBody = 512
+
+// HTTP level errors, matched by APIError using errors.Is. Private,
+// quarantined and banned subreddits also match ErrForbidden or ErrNotFound
+// depending on the status code Reddit replies with.
+var (
+	ErrBanned           = errors.New("subreddit is banned")
+	ErrForbidden        = errors.New("forbidden")
+	ErrNotFound         = errors.New("not found")
+	ErrPrivateSubreddit = errors.New("subreddit is private")
+	ErrQuarantined      = errors.New("subreddit is quarantined")
+	ErrServerError      = errors.New("server error")
+	ErrUnauthorized     = errors.New("unauthorized")
+)
+
+// ErrorCode is a Reddit API error code. Codes are errors themselves and
+// match any APIError reporting them, e.g. errors.Is(err, ErrWrongPassword).
+type ErrorCode string
+
+// Reddit API error codes
+const (
+	ErrAlreadySub          ErrorCode = "ALREADY_SUB"
+	ErrBadCaptcha          ErrorCode = "BAD_CAPTCHA"
+	ErrBadFlairTarget      ErrorCode = "BAD_FLAIR_TARGET"
+	ErrBadSubredditName    ErrorCode = "BAD_SR_NAME"
+	ErrBadURL              ErrorCode = "BAD_URL"
+	ErrDeletedComment      ErrorCode = "DELETED_COMMENT"
+	ErrDeletedLink         ErrorCode = "DELETED_LINK"
+	ErrEditConflict        ErrorCode = "EDIT_CONFLICT"
+	ErrInvalidOption       ErrorCode = "INVALID_OPTION"
+	ErrNoLinks             ErrorCode = "NO_LINKS"
+	ErrNoSelfs             ErrorCode = "NO_SELFS"
+	ErrNoText              ErrorCode = "NO_TEXT"
+	ErrNotAuthor           ErrorCode = "NOT_AUTHOR"
+	ErrRateLimited         ErrorCode = "RATELIMIT"
+	ErrSubredditNoExist    ErrorCode = "SUBREDDIT_NOEXIST"
+	ErrSubredditNotAllowed ErrorCode = "SUBREDDIT_NOTALLOWED"
+	ErrThreadLocked        ErrorCode = "THREAD_LOCKED"
+	ErrTooLong             ErrorCode = "TOO_LONG"
+	ErrTooOld              ErrorCode = "TOO_OLD"
+	ErrUserDoesntExist     ErrorCode = "USER_DOESNT_EXIST"
+	ErrUserRequired        ErrorCode = "USER_REQUIRED"
+	ErrWrongPassword       ErrorCode = "WRONG_PASSWORD"
+)
+
+// Error returns the error code
+func (c ErrorCode) Error() string {
+	return string(c)
+}
+
+// ErrorEntry is a single error reported by a Reddit API reply
+type ErrorEntry struct {
+	Code    ErrorCode // Error code, e.g. ErrRateLimited
+	Field   string    // Name of the offending request field, if any
+	Message string    // Human readable description
+}
+
+// APIError represents a Reddit API error. Errors reported in the reply
+// body are listed in Errors, failed HTTP requests have no entries.
 type APIError struct {
-	id   string
-	desc string
-	wait time.Time
+	Body       string       // Start of the reply body of a failed HTTP request
+	Errors     []ErrorEntry // All reported errors, in order
+	Path       string       // Path of the failed request
+	Reason     string       // Reason given for a failed HTTP request, e.g. "private"
+	StatusCode int          // HTTP status code of the reply, 0 if unknown
+	wait       time.Time
 }
 
 func newAPIError(e *jsonAPIReply) error {
 	if len(e.Errors) == 0 {
 		return nil
 	}
-	// FIXME: This will explode if Errors is not a two-dimensional array
 	err := APIError{
-		id:   e.Errors[0][0],
-		desc: e.Errors[0][1],
 		wait: time.Now(),
 	}
+	// Reddit sends [code, message, field] triplets but nothing
+	// is assumed about the length of each entry
+	for _, row := range e.Errors {
+		entry := ErrorEntry{}
+		if len(row) > 0 {
+			entry.Code = ErrorCode(row[0])
+		}
+		if len(row) > 1 {
+			entry.Message = row[1]
+		}
+		if len(row) > 2 {
+			entry.Field = row[2]
+		}
+		err.Errors = append(err.Errors, entry)
+	}
 	if e.Ratelimit > 0 {
 		err.wait = err.wait.Add(time.Duration(e.Ratelimit) * time.Second)
 	}
 	return err
 }
 
+// newStatusError returns an APIError for a failed HTTP request. The
+// reply body is consumed.
+func newStatusError(resp *http.Response) error {
+	err := APIError{
+		StatusCode: resp.StatusCode,
+		wait:       time.Now(),
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		err.Path = resp.Request.URL.Path
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	err.Body = string(body)
+
+	// Reddit replies e.g. {"reason": "private", "message": "Forbidden", "error": 403}
+	reply := struct {
+		Reason string `json:"reason"`
+	}{}
+	if json.Unmarshal(body, &reply) == nil {
+		err.Reason = reply.Reason
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		reset, _ := strconv.Atoi(resp.Header.Get("X-Ratelimit-Reset"))
+		err.wait = err.wait.Add(time.Duration(reset) * time.Second)
+	}
+	return err
+}
+
+// withResponse adds the status code and request path of resp to err if
+// it is an APIError
+func withResponse(err error, resp *http.Response) error {
+	apierr, ok := err.(APIError)
+	if !ok {
+		return err
+	}
+	apierr.StatusCode = resp.StatusCode
+	if resp.Request != nil && resp.Request.URL != nil {
+		apierr.Path = resp.Request.URL.Path
+	}
+	return apierr
+}
+
 // Error returns a descriptive string of the error
 func (e APIError) Error() string {
-	return fmt.Sprintf("%s: %s", e.id, e.desc)
+	if len(e.Errors) == 0 {
+		s := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+		if len(e.Reason) > 0 {
+			s += fmt.Sprintf(": %s", e.Reason)
+		}
+		if len(e.Path) > 0 {
+			s += fmt.Sprintf(" (%s)", e.Path)
+		}
+		return s
+	}
+
+	var msgs []string
+	for _, entry := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", entry.Code, entry.Message))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Code returns the code of the first reported error, empty if none
+func (e APIError) Code() ErrorCode {
+	if len(e.Errors) == 0 {
+		return ""
+	}
+	return e.Errors[0].Code
+}
+
+// Has returns true if error code c is among the reported errors
+func (e APIError) Has(c ErrorCode) bool {
+	for _, entry := range e.Errors {
+		if entry.Code == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Is reports whether the error matches target, allowing
+// errors.Is(err, ErrRateLimited), errors.Is(err, ErrNotFound)
+// and similar checks.
+func (e APIError) Is(target error) bool {
+	switch target {
+	case ErrBanned:
+		return e.Reason == "banned"
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrPrivateSubreddit:
+		return e.Reason == "private"
+	case ErrQuarantined:
+		return e.Reason == "quarantined"
+	case ErrServerError:
+		return e.StatusCode >= http.StatusInternalServerError
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrRateLimited:
+		if e.StatusCode == http.StatusTooManyRequests || e.IsRatelimited() {
+			return true
+		}
+	}
+
+	c, ok := target.(ErrorCode)
+	if !ok {
+		return false
+	}
+	return e.Has(c)
 }
 
 // IsRatelimited returns true if a ratelimit is in effect for the error
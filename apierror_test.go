@@ -2,7 +2,10 @@ package rego
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"net/http"
+	"reflect"
 	"testing"
 )
 
@@ -49,3 +52,127 @@ func TestAPIError(t *testing.T) {
 		}
 	}
 }
+
+func TestAPIErrorEntries(t *testing.T) {
+	var tests = []struct {
+		json    string
+		entries []ErrorEntry
+		is      []error
+	}{
+		{`{"json": {"errors": [["NO_TEXT", "we need something here", "title"], ["TOO_LONG", "too long", "text"]]}}`,
+			[]ErrorEntry{{ErrNoText, "title", "we need something here"}, {ErrTooLong, "text", "too long"}},
+			[]error{ErrNoText, ErrTooLong}},
+		{`{"json": {"errors": [["ALREADY_SUB"], []]}}`,
+			[]ErrorEntry{{ErrAlreadySub, "", ""}, {"", "", ""}},
+			[]error{ErrAlreadySub}},
+		{`{"json": {"ratelimit": 10, "errors": [["RATELIMIT", "try again later", "ratelimit"]]}}`,
+			[]ErrorEntry{{ErrRateLimited, "ratelimit", "try again later"}},
+			[]error{ErrRateLimited}},
+	}
+
+	for _, test := range tests {
+		_, err := getJSON(bytes.NewBufferString(test.json))
+		var apierr APIError
+		if !errors.As(err, &apierr) {
+			t.Errorf("Got %v, wanted APIError", err)
+			continue
+		}
+		if !reflect.DeepEqual(apierr.Errors, test.entries) {
+			t.Errorf("Got entries %v, wanted %v", apierr.Errors, test.entries)
+		}
+		for _, target := range test.is {
+			if !errors.Is(err, target) {
+				t.Errorf("errors.Is(%v, %v) returned false", err, target)
+			}
+		}
+		if errors.Is(err, ErrWrongPassword) {
+			t.Errorf("errors.Is(%v, ErrWrongPassword) returned true", err)
+		}
+	}
+}
+
+func TestAPIErrorStatus(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == apiComment {
+			fmt.Fprint(w, `{"json": {"errors": [["THREAD_LOCKED", "comments are locked", "parent"]]}}`)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	var tests = []struct {
+		err    error
+		status int
+		path   string
+	}{
+		{s.Login("user", "pass"), http.StatusServiceUnavailable, apiLogin},
+		{s.SetUserFlair("test", "user", "text", ""), http.StatusServiceUnavailable, "/r/test/api/flair"},
+		{func() error { _, err := s.Comment("t3_abc", "text"); return err }(), http.StatusOK, apiComment},
+	}
+
+	for _, test := range tests {
+		var apierr APIError
+		if !errors.As(test.err, &apierr) {
+			t.Errorf("Got %v, wanted APIError", test.err)
+			continue
+		}
+		if apierr.StatusCode != test.status || apierr.Path != test.path {
+			t.Errorf("Got %d %s, wanted %d %s", apierr.StatusCode, apierr.Path, test.status, test.path)
+		}
+	}
+}
+
+func TestHTTPErrors(t *testing.T) {
+	var tests = []struct {
+		status int
+		body   string
+		is     []error
+		isNot  []error
+	}{
+		{http.StatusUnauthorized, `{"message": "Unauthorized", "error": 401}`, []error{ErrUnauthorized}, []error{ErrForbidden}},
+		{http.StatusForbidden, `{"reason": "private", "message": "Forbidden", "error": 403}`, []error{ErrForbidden, ErrPrivateSubreddit}, []error{ErrQuarantined}},
+		{http.StatusForbidden, `{"reason": "quarantined", "message": "Forbidden", "error": 403}`, []error{ErrForbidden, ErrQuarantined}, []error{ErrPrivateSubreddit}},
+		{http.StatusNotFound, `{"reason": "banned", "message": "Not Found", "error": 404}`, []error{ErrNotFound, ErrBanned}, []error{ErrForbidden}},
+		{http.StatusTooManyRequests, `<html>Too Many Requests</html>`, []error{ErrRateLimited}, []error{ErrServerError}},
+		{http.StatusBadGateway, `<html>Bad Gateway</html>`, []error{ErrServerError}, []error{ErrNotFound}},
+	}
+
+	for _, test := range tests {
+		s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			fmt.Fprint(w, test.body)
+		}))
+
+		_, err := s.User("someone")
+		for _, target := range test.is {
+			if !errors.Is(err, target) {
+				t.Errorf("%d: errors.Is(%v, %v) returned false", test.status, err, target)
+			}
+		}
+		for _, target := range test.isNot {
+			if errors.Is(err, target) {
+				t.Errorf("%d: errors.Is(%v, %v) returned true", test.status, err, target)
+			}
+		}
+		var apierr APIError
+		if errors.As(err, &apierr) && apierr.Body != test.body {
+			t.Errorf("Got body %q, wanted %q", apierr.Body, test.body)
+		}
+		ts.Close()
+	}
+}
+
+func TestUnexpectedKind(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"kind": "t5", "data": {}}`)
+	}))
+	defer ts.Close()
+
+	if _, err := s.Listing("r/test").Next(); err != ErrUnexpectedKind {
+		t.Errorf("Got %v, wanted ErrUnexpectedKind", err)
+	}
+	if _, err := s.Me(); err != ErrUnexpectedKind {
+		t.Errorf("Got %v, wanted ErrUnexpectedKind", err)
+	}
+}
@@ -0,0 +1,296 @@
+package rego
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is the interface of response cache backends used by Session.SetCache.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, e *CacheEntry) error
+	Delete(key string)
+}
+
+// CacheEntry is a cached reply
+type CacheEntry struct {
+	Body         []byte      // Reply body
+	ETag         string      // ETag header, used for conditional requests
+	Expires      time.Time   // Time the entry must be revalidated
+	Header       http.Header // Reply headers
+	LastModified string      // Last-Modified header, used for conditional requests
+	URL          string      // Final URL of the request, after redirects
+}
+
+// cacheTTL is the TTL of endpoints matching a path pattern
+type cacheTTL struct {
+	pattern string
+	ttl     time.Duration
+}
+
+// SetCache enables caching of unauthenticated GET replies in c, or disables
+// it if c is nil. Entries are kept for ttl, unless overridden for an endpoint
+// by SetCacheTTL, and are revalidated using conditional requests when expired.
+//
+// Authenticated sessions bypass the cache as replies include fields specific
+// to the user, e.g. votes and the modhash. Fresh replies bypass the request
+// pipeline, see Use.
+func (s *Session) SetCache(c Cache, ttl time.Duration) {
+	s.cache = c
+	s.cacheTTL = ttl
+}
+
+// SetCacheTTL sets the TTL of cached replies of endpoints with a path matching
+// pattern, e.g. "/user/*/about.json" or "/r/*/new.json". Patterns use the
+// syntax of path.Match and are tried in the order set. A zero ttl makes every
+// use of an entry revalidate it.
+func (s *Session) SetCacheTTL(pattern string, ttl time.Duration) {
+	s.cacheTTLs = append(s.cacheTTLs, cacheTTL{pattern, ttl})
+}
+
+// ttl returns the cache TTL of endpoint path p
+func (s *Session) ttl(p string) time.Duration {
+	for _, t := range s.cacheTTLs {
+		if ok, _ := path.Match(t.pattern, p); ok {
+			return t.ttl
+		}
+	}
+	return s.cacheTTL
+}
+
+// cached sends GET request req using the cache. Fresh entries are replied
+// without a request, expired ones are revalidated.
+func (s *Session) cached(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+	e, ok := s.cache.Get(key)
+	if ok && time.Now().Before(e.Expires) {
+		return e.response(req), nil
+	}
+	if ok {
+		if len(e.ETag) > 0 {
+			req.Header.Set("If-None-Match", e.ETag)
+		}
+		if len(e.LastModified) > 0 {
+			req.Header.Set("If-Modified-Since", e.LastModified)
+		}
+	}
+
+	resp, err := s.send(req)
+	if err != nil {
+		return nil, err
+	}
+	expires := time.Now().Add(s.ttl(req.URL.Path))
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		e.Expires = expires
+		s.setCache(req, key, e)
+		return e.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK || strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Middleware may reply without setting the request
+	final := req.URL
+	if resp.Request != nil {
+		final = resp.Request.URL
+	}
+	s.setCache(req, key, &CacheEntry{
+		Body:         body,
+		ETag:         resp.Header.Get("ETag"),
+		Expires:      expires,
+		Header:       resp.Header.Clone(),
+		LastModified: resp.Header.Get("Last-Modified"),
+		URL:          final.String(),
+	})
+	return resp, nil
+}
+
+// setCache stores e as the entry of key, logging failures as the reply is
+// still good
+func (s *Session) setCache(req *http.Request, key string, e *CacheEntry) {
+	err := s.cache.Set(key, e)
+	if err != nil && s.logger != nil {
+		s.logger.LogAttrs(req.Context(), slog.LevelWarn, "reddit cache",
+			slog.String("endpoint", endpointTemplate(req.URL.Path)),
+			slog.String("error", err.Error()))
+	}
+}
+
+// response returns the entry as the reply to req
+func (e *CacheEntry) response(req *http.Request) *http.Response {
+	r := req
+	if u, err := url.Parse(e.URL); err == nil && e.URL != req.URL.String() {
+		r = req.Clone(req.Context())
+		r.URL = u
+	}
+	return &http.Response{
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Header:        e.Header.Clone(),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Request:       r,
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+	}
+}
+
+// MemoryCache is an in-memory Cache evicting the least recently used entries
+type MemoryCache struct {
+	entries map[string]*list.Element
+	lock    sync.Mutex
+	lru     *list.List // Keys, most recently used first
+	size    int
+}
+
+// memoryItem is an entry of the LRU list
+type memoryItem struct {
+	entry *CacheEntry
+	key   string
+}
+
+// NewMemoryCache returns a MemoryCache keeping at most size entries
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+		size:    size,
+	}
+}
+
+// Get returns the entry of key
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	e := *el.Value.(*memoryItem).entry
+	return &e, true
+}
+
+// Set stores e as the entry of key, evicting the least recently used entry
+// if the cache is full
+func (c *MemoryCache) Set(key string, e *CacheEntry) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*memoryItem).entry = e
+		c.lru.MoveToFront(el)
+		return nil
+	}
+	c.entries[key] = c.lru.PushFront(&memoryItem{e, key})
+	for c.lru.Len() > c.size {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+// Delete removes the entry of key
+func (c *MemoryCache) Delete(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+// Len returns the number of entries
+func (c *MemoryCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Len()
+}
+
+// DiskCache is a Cache storing entries as files in a directory
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a DiskCache storing entries in dir, which is created
+// if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get returns the entry of key
+func (c *DiskCache) Get(key string) (*CacheEntry, bool) {
+	b, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	e := CacheEntry{}
+	if json.Unmarshal(b, &e) != nil {
+		return nil, false
+	}
+	return &e, true
+}
+
+// Set stores e as the entry of key. Entries are written to a temporary file
+// first, so that concurrent readers never see a partial entry. The temporary
+// file is removed if the entry can not be stored.
+func (c *DiskCache) Set(key string, e *CacheEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(c.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Delete removes the entry of key
+func (c *DiskCache) Delete(key string) {
+	os.Remove(c.path(key))
+}
+
+// path returns the file name of the entry of key
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
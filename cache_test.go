@@ -0,0 +1,177 @@
+package rego
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Cache(t *testing.T) {
+	hits := map[string]int{}
+	notModified := 0
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		etag := fmt.Sprintf(`"%s"`, r.URL.Path)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, `{"kind": "t2", "data": {"name": "bob", "modhash": ""}}`)
+	}))
+	defer ts.Close()
+
+	s.SetCache(NewMemoryCache(10), time.Hour)
+	s.SetCacheTTL("/user/alice/*", 0)
+
+	for i := 0; i < 3; i++ {
+		for _, u := range []string{"bob", "alice"} {
+			acct, err := s.User(u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if acct.Name != "bob" {
+				t.Errorf("Got %q, wanted the cached account", acct.Name)
+			}
+		}
+	}
+	if hits["/user/bob/about.json"] != 1 {
+		t.Errorf("Got %d requests, wanted the fresh entry used", hits["/user/bob/about.json"])
+	}
+	if hits["/user/alice/about.json"] != 3 || notModified != 2 {
+		t.Errorf("Got %d requests, %d not modified, wanted the entry revalidated", hits["/user/alice/about.json"], notModified)
+	}
+
+	// Authenticated sessions bypass the cache
+	s.cookie = "reddit_session=x"
+	_, err := s.User("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hits["/user/bob/about.json"] != 2 {
+		t.Errorf("Got %d requests, wanted the cache bypassed", hits["/user/bob/about.json"])
+	}
+}
+
+func Test_CachePipeline(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining", "10")
+		fmt.Fprint(w, `{"kind": "t2", "data": {"name": "bob", "modhash": ""}}`)
+	}))
+	defer ts.Close()
+	sent := 0
+	s.Use(func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			sent++
+			return next.Do(req)
+		})
+	})
+	s.SetCache(NewMemoryCache(10), time.Hour)
+
+	for i := 0; i < 3; i++ {
+		_, err := s.User("bob")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Fresh replies of the cache bypass the middleware
+	if sent != 1 {
+		t.Errorf("Got %d requests through the middleware, wanted 1", sent)
+	}
+	if rl := s.RateLimit(); rl.Remaining != 10 {
+		t.Errorf("Got remaining %d, wanted the rate limit of the sent request", rl.Remaining)
+	}
+}
+
+func Test_MemoryCache(t *testing.T) {
+	c := NewMemoryCache(2)
+	c.Set("a", &CacheEntry{ETag: "a"})
+	c.Set("b", &CacheEntry{ETag: "b"})
+	c.Get("a")
+	c.Set("c", &CacheEntry{ETag: "c"})
+
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("Least recently used entry not evicted")
+	}
+	if e, ok := c.Get("a"); !ok || e.ETag != "a" {
+		t.Errorf("Got %v, wanted entry a", e)
+	}
+	c.Delete("a")
+	if c.Len() != 1 {
+		t.Errorf("Got %d entries, wanted 1", c.Len())
+	}
+}
+
+func Test_DiskCache(t *testing.T) {
+	c, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(time.Minute).Truncate(time.Second)
+	err = c.Set("https://www.reddit.com/r/golang/new.json?limit=5", &CacheEntry{
+		Body:    []byte(`{}`),
+		ETag:    `"x"`,
+		Expires: expires,
+		Header:  http.Header{"Content-Type": {"application/json"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, ok := c.Get("https://www.reddit.com/r/golang/new.json?limit=5")
+	if !ok || string(e.Body) != `{}` || e.ETag != `"x"` || !e.Expires.Equal(expires) || e.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Got %+v, wanted the stored entry", e)
+	}
+	c.Delete("https://www.reddit.com/r/golang/new.json?limit=5")
+	if _, ok := c.Get("https://www.reddit.com/r/golang/new.json?limit=5"); ok {
+		t.Errorf("Deleted entry found")
+	}
+
+	// A directory in place of the entry fails the rename
+	err = os.MkdirAll(filepath.Join(c.path("blocked"), "x"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Set("blocked", &CacheEntry{Body: []byte(`{}`)})
+	if err == nil {
+		t.Errorf("Got no error storing over a directory")
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(c.dir, "tmp-*")); len(tmp) > 0 {
+		t.Errorf("Got temporary files %q left", tmp)
+	}
+}
+
+// failingCache is a Cache failing to store entries
+type failingCache struct {
+	*MemoryCache
+}
+
+func (c *failingCache) Set(key string, e *CacheEntry) error {
+	return errors.New("disk full")
+}
+
+func Test_CacheSetError(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"kind": "t2", "data": {"name": "bob", "modhash": ""}}`)
+	}))
+	defer ts.Close()
+	buf := bytes.Buffer{}
+	s.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)), slog.LevelDebug)
+	s.SetCache(&failingCache{NewMemoryCache(10)}, time.Hour)
+
+	acct, err := s.User("bob")
+	if err != nil || acct.Name != "bob" {
+		t.Fatalf("Got %v, %v, wanted the reply despite the cache failure", acct, err)
+	}
+	if !strings.Contains(buf.String(), `msg="reddit cache" endpoint=/user/%s/about.json error="disk full"`) {
+		t.Errorf("Got log %q, wanted the cache failure logged", buf.String())
+	}
+}
@@ -0,0 +1,283 @@
+// Package cassette records HTTP exchanges made through a rego.Session into
+// a cassette file and replays them, letting tests run offline and
+// deterministically.
+//
+// Record the exchanges once against Reddit:
+//
+//	rec, err := cassette.New("testdata/listing.json", cassette.ModeRecord)
+//	s := rego.NewSession("TestBot/1.0")
+//	s.SetClient(rec.Client())
+//	... // Use the session
+//	err = rec.Save()
+//
+// Tests then replay them using cassette.ModeReplay. Credentials, i.e.
+// the Authorization, Cookie and X-Modhash headers, passwords, modhashes
+// and tokens, are redacted before being stored.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mode selects whether a Recorder records or replays
+type Mode int
+
+// Recorder modes
+const (
+	ModeRecord Mode = iota // Send requests and record the exchanges
+	ModeReplay             // Reply from the cassette without sending requests
+)
+
+// Redacted replaces the values of redacted credentials
+const Redacted = "REDACTED"
+
+var (
+	ErrNoInteraction = errors.New("no matching interaction in cassette")
+)
+
+// Redacted headers, form and query fields
+var (
+	redactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Modhash"}
+	redactFields  = []string{"access_token", "modhash", "passwd", "password", "refresh_token", "uh"}
+)
+
+// redactForm matches credential fields of url encoded values, used when the
+// values can not be parsed
+var redactForm = regexp.MustCompile(`(^|[&;])(access_token|modhash|passwd|password|refresh_token|uh)=[^&;]*`)
+
+// redactJSON matches credential members of JSON bodies
+var redactJSON = regexp.MustCompile(`"(access_token|cookie|modhash|passwd|password|refresh_token)"(\s*):(\s*)"(?:[^"\\]|\\.)*"`)
+
+// Cassette is the stored list of recorded exchanges
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded, redacted HTTP request
+type Request struct {
+	Body   string      `json:"body,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+}
+
+// Response is a recorded, redacted HTTP response
+type Response struct {
+	Body   string      `json:"body"`
+	Header http.Header `json:"header,omitempty"`
+	Status int         `json:"status"`
+}
+
+// Matcher reports whether the redacted request r matches recorded request i
+type Matcher func(r Request, i Request) bool
+
+// MatchMethodURL matches requests by method and URL, including the query
+func MatchMethodURL(r Request, i Request) bool {
+	return r.Method == i.Method && r.URL == i.URL
+}
+
+// MatchBody matches requests by method, URL and body
+func MatchBody(r Request, i Request) bool {
+	return MatchMethodURL(r, i) && r.Body == i.Body
+}
+
+// Recorder is an http.RoundTripper recording or replaying a cassette.
+// It is safe for concurrent use.
+type Recorder struct {
+	Match     Matcher           // Request matching used in replay mode, MatchBody if nil
+	Transport http.RoundTripper // Transport used in record mode, http.DefaultTransport if nil
+
+	cassette Cassette
+	lock     sync.Mutex
+	mode     Mode
+	path     string
+	used     []bool // Replayed interactions
+}
+
+// New returns a Recorder for the cassette file at path. In replay mode the
+// cassette is loaded from the file, in record mode it is written by Save.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path}
+	if mode != ModeReplay {
+		return r, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &r.cassette)
+	if err != nil {
+		return nil, err
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Client returns an HTTP client using the recorder. Use it with
+// rego.Session.SetClient.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns the recorded or loaded interactions
+func (r *Recorder) Interactions() []Interaction {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// Save writes the recorded cassette to its file
+func (r *Recorder) Save() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	b, err := json.MarshalIndent(r.cassette, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(b, '\n'), 0644)
+}
+
+// RoundTrip records or replays the exchange of req
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := newRequest(req, body)
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, recorded Request) (*http.Response, error) {
+	t := r.Transport
+	if t == nil {
+		t = http.DefaultTransport
+	}
+	resp, err := t.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			Body:   redactJSON.ReplaceAllString(string(body), `"$1"$2:$3"`+Redacted+`"`),
+			Header: redactHeader(resp.Header),
+			Status: resp.StatusCode,
+		},
+	})
+	return resp, nil
+}
+
+// replay replies with the first unused interaction matching the request
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	match := r.Match
+	if match == nil {
+		match = MatchBody
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for i, it := range r.cassette.Interactions {
+		if r.used[i] || !match(recorded, it.Request) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Body:          io.NopCloser(strings.NewReader(it.Response.Body)),
+			ContentLength: int64(len(it.Response.Body)),
+			Header:        it.Response.Header.Clone(),
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Request:       req,
+			Status:        fmt.Sprintf("%d %s", it.Response.Status, http.StatusText(it.Response.Status)),
+			StatusCode:    it.Response.Status,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+}
+
+// newRequest returns the redacted recording of req with body
+func newRequest(req *http.Request, body []byte) Request {
+	u := *req.URL
+	u.RawQuery = redactValues(u.RawQuery)
+
+	b := string(body)
+	switch {
+	case strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded"):
+		b = redactValues(b)
+	case strings.HasPrefix(req.Header.Get("Content-Type"), "application/json"):
+		b = redactJSON.ReplaceAllString(b, `"$1"$2:$3"`+Redacted+`"`)
+	}
+
+	return Request{
+		Body:   b,
+		Header: redactHeader(req.Header),
+		Method: req.Method,
+		URL:    u.String(),
+	}
+}
+
+// redactHeader returns a copy of h with credential headers redacted
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, k := range redactHeaders {
+		if _, ok := h[k]; ok {
+			h.Set(k, Redacted)
+		}
+	}
+	return h
+}
+
+// redactValues redacts the credential fields of the url encoded values s.
+// Values that can not be parsed are redacted by matching key=value pairs.
+func redactValues(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	v, err := url.ParseQuery(s)
+	if err != nil {
+		return redactForm.ReplaceAllString(s, "${1}${2}="+Redacted)
+	}
+	for _, k := range redactFields {
+		if _, ok := v[k]; ok {
+			v.Set(k, Redacted)
+		}
+	}
+	return v.Encode()
+}
@@ -0,0 +1,152 @@
+package cassette
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/c0rner/rego"
+	"github.com/c0rner/rego/redditest"
+)
+
+// exercise runs the session requests recorded and replayed by the tests
+func exercise(t *testing.T, s *rego.Session) {
+	t.Helper()
+	err := s.Login("bob", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := s.Listing("r/golang/new")
+	list, err := page.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	links := list.Links()
+	if len(links) != 2 || links[0].Title != "Second" {
+		t.Fatalf("Got %#v, wanted the two links", links)
+	}
+
+	cr, err := s.Comment(rego.Fullname(links[0].Name), "Hi")
+	if err != nil || len(cr.ID) == 0 {
+		t.Errorf("Got %v, %v, wanted a comment", cr, err)
+	}
+
+	_, err = s.Subreddit("nosuchsub")
+	if !errors.Is(err, rego.ErrNotFound) {
+		t.Errorf("Got %v, wanted ErrNotFound", err)
+	}
+}
+
+func record(t *testing.T) string {
+	srv := redditest.NewServer()
+	defer srv.Close()
+	srv.AddUser("bob", "hunter2")
+	srv.AddLink("golang", rego.Link{Title: "First"})
+	srv.AddLink("golang", rego.Link{Title: "Second"})
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Transport = srv.Client().Transport
+
+	s := rego.NewSession("CassetteBot/1.0")
+	s.SetClient(rec.Client())
+	exercise(t, s)
+
+	err = rec.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := record(t)
+
+	// The server is closed, all replies come from the cassette
+	rec, err := New(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(rec.Interactions()); n != 4 {
+		t.Errorf("Got %d interactions, wanted 4", n)
+	}
+	s := rego.NewSession("CassetteBot/1.0")
+	s.SetClient(rec.Client())
+	exercise(t, s)
+
+	// Each interaction is replayed once
+	_, err = s.Subreddit("nosuchsub")
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("Got %v, wanted ErrNoInteraction", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	path := record(t)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2", "reddit_session=bob", `"cookie": "bob`, "mh"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("Cassette contains %q", secret)
+		}
+	}
+
+	req, _ := http.NewRequest("POST", "https://www.reddit.com/api/login?uh=secret&x=1", strings.NewReader(`{"password": "secret", "user": "bob"}`))
+	req.Header.Set("Authorization", "bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	r := newRequest(req, []byte(`{"password": "secret", "user": "bob"}`))
+	if strings.Contains(r.URL, "secret") || strings.Contains(r.Body, "secret") || r.Header.Get("Authorization") != Redacted {
+		t.Errorf("Got %#v, wanted credentials redacted", r)
+	}
+	if r.Body != `{"password": "REDACTED", "user": "bob"}` {
+		t.Errorf("Got body %s", r.Body)
+	}
+}
+
+func TestRedactUnparseable(t *testing.T) {
+	var tests = []struct {
+		in, out string
+	}{
+		{"user=bob&passwd=hunter2%zz&x=1", "user=bob&passwd=REDACTED&x=1"},
+		{"passwd=hunter2&api_type=%", "passwd=REDACTED&api_type=%"},
+		{"a=%;uh=secret;b=2", "a=%;uh=REDACTED;b=2"},
+		{"user=bob&rem=%", "user=bob&rem=%"},
+	}
+
+	for _, test := range tests {
+		if r := redactValues(test.in); r != test.out {
+			t.Errorf("Got %q for %q, wanted %q", r, test.in, test.out)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	var tests = []struct {
+		a, b Request
+		url  bool
+		body bool
+	}{
+		{Request{Method: "GET", URL: "/a"}, Request{Method: "GET", URL: "/a"}, true, true},
+		{Request{Method: "GET", URL: "/a"}, Request{Method: "POST", URL: "/a"}, false, false},
+		{Request{Method: "GET", URL: "/a?x=1"}, Request{Method: "GET", URL: "/a"}, false, false},
+		{Request{Method: "POST", URL: "/a", Body: "x=1"}, Request{Method: "POST", URL: "/a", Body: "x=2"}, true, false},
+	}
+
+	for i, tt := range tests {
+		if m := MatchMethodURL(tt.a, tt.b); m != tt.url {
+			t.Errorf("%d: MatchMethodURL got %t, wanted %t", i, m, tt.url)
+		}
+		if m := MatchBody(tt.a, tt.b); m != tt.body {
+			t.Errorf("%d: MatchBody got %t, wanted %t", i, m, tt.body)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package rego
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoConfig     = errors.New("no config file found")
+	ErrSiteNotFound = errors.New("config site not found")
+)
+
+// DefaultSite is the config site used when none is given
+const DefaultSite = "DEFAULT"
+
+// Config holds the settings of a named site of a config file
+type Config struct {
+	ClientID     string // OAuth client ID, kept for praw.ini compatibility
+	ClientSecret string // OAuth client secret, kept for praw.ini compatibility
+	Password     string // Account password
+	Site         string // Name of the site
+	UserAgent    string // User agent, see ValidateUserAgent
+	Username     string // Account name
+}
+
+// configKeys maps config file keys to the Config fields
+var configKeys = map[string]func(*Config) *string{
+	"client_id":     func(c *Config) *string { return &c.ClientID },
+	"client_secret": func(c *Config) *string { return &c.ClientSecret },
+	"password":      func(c *Config) *string { return &c.Password },
+	"user_agent":    func(c *Config) *string { return &c.UserAgent },
+	"username":      func(c *Config) *string { return &c.Username },
+}
+
+// userAgentFormat is the user agent format required by Reddit, e.g.
+// "linux:com.example.bot:v1.0 (by /u/example)"
+var userAgentFormat = regexp.MustCompile(`^[^:\s]+:[^:\s]+:[^:\s]+ \(by /?u/[A-Za-z0-9_-]{3,20}\)$`)
+
+// NewSessionFromConfig returns a session set up using the settings of site
+// loaded by LoadConfig. The session is logged in if a username is set.
+func NewSessionFromConfig(site string) (*Session, error) {
+	c, err := LoadConfig(site)
+	if err != nil {
+		return nil, err
+	}
+	err = ValidateUserAgent(c.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewSession(c.UserAgent)
+	if len(c.Username) > 0 {
+		err = s.Login(c.Username, c.Password)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// LoadConfig returns the settings of site. If site is empty, the site set by
+// the praw_site or REGO_SITE environment variables is used, else DefaultSite.
+//
+// Settings are read from the file named by REGO_CONFIG, or else the first
+// found of rego.toml and praw.ini in the working directory and in the user
+// config directory, e.g. ~/.config. Files ending in .toml are parsed as TOML
+// tables of strings, others as INI files. Like praw.ini, settings of the
+// DEFAULT section, or the top level of TOML files, apply to all sites.
+//
+// Settings are overridden by the environment variables praw_<key> and
+// REGO_<KEY>, e.g. praw_username or REGO_USERNAME, the latter taking
+// precedence. Keys are client_id, client_secret, username, password and
+// user_agent.
+func LoadConfig(site string) (*Config, error) {
+	if len(site) == 0 {
+		site = DefaultSite
+		for _, env := range []string{"praw_site", "REGO_SITE"} {
+			if v, ok := os.LookupEnv(env); ok {
+				site = v
+			}
+		}
+	}
+
+	path, err := configPath()
+	if err != nil && !(errors.Is(err, ErrNoConfig) && site == DefaultSite) {
+		return nil, err
+	}
+
+	sites := map[string]map[string]string{}
+	if len(path) > 0 {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if strings.HasSuffix(path, ".toml") {
+			sites, err = parseTOML(f)
+		} else {
+			sites, err = parseINI(f)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	values, ok := sites[site]
+	if !ok && site != DefaultSite {
+		return nil, fmt.Errorf("%w: %s", ErrSiteNotFound, site)
+	}
+	c := Config{Site: site}
+	for _, section := range []map[string]string{sites[DefaultSite], values} {
+		for k, v := range section {
+			if field, ok := configKeys[k]; ok {
+				*field(&c) = v
+			}
+		}
+	}
+	for k, field := range configKeys {
+		for _, env := range []string{"praw_" + k, "REGO_" + strings.ToUpper(k)} {
+			if v, ok := os.LookupEnv(env); ok {
+				*field(&c) = v
+			}
+		}
+	}
+	return &c, nil
+}
+
+// ValidateUserAgent returns an error wrapping ErrUserAgent unless ua follows
+// the format required by Reddit, <platform>:<app ID>:<version> (by /u/<username>),
+// e.g. "linux:com.example.bot:v1.0 (by /u/example)"
+func ValidateUserAgent(ua string) error {
+	if genericUserAgent(ua) || !userAgentFormat.MatchString(ua) {
+		return fmt.Errorf("%w: %q, want <platform>:<app ID>:<version> (by /u/<username>)", ErrUserAgent, ua)
+	}
+	return nil
+}
+
+// configPath returns the path of the config file to use
+func configPath() (string, error) {
+	if p, ok := os.LookupEnv("REGO_CONFIG"); ok {
+		return p, nil
+	}
+	dirs := []string{"."}
+	if d, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, d)
+	}
+	for _, d := range dirs {
+		for _, name := range []string{"rego.toml", "praw.ini"} {
+			p := filepath.Join(d, name)
+			if _, err := os.Stat(p); err == nil {
+				return p, nil
+			}
+		}
+	}
+	return "", ErrNoConfig
+}
+
+// parseINI parses the sections of an INI file. Keys preceding the first
+// section belong to DefaultSite.
+func parseINI(r io.Reader) (map[string]map[string]string, error) {
+	sites := map[string]map[string]string{DefaultSite: {}}
+	section := DefaultSite
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("line %d: bad section %q", n, line)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := sites[section]; !ok {
+				sites[section] = map[string]string{}
+			}
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: missing value of %q", n, line)
+		}
+		sites[section][strings.ToLower(strings.TrimSpace(line[:i]))] = strings.TrimSpace(line[i+1:])
+	}
+	return sites, scanner.Err()
+}
+
+// parseTOML parses the tables of a TOML file holding string values, the
+// subset of TOML needed for config files. Keys preceding the first table
+// belong to DefaultSite.
+func parseTOML(r io.Reader) (map[string]map[string]string, error) {
+	sites := map[string]map[string]string{DefaultSite: {}}
+	table := DefaultSite
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 || !tomlComment(line[end+1:]) {
+				return nil, fmt.Errorf("line %d: bad table %q", n, line)
+			}
+			table = tomlKey(line[1:end])
+			if _, ok := sites[table]; !ok {
+				sites[table] = map[string]string{}
+			}
+			continue
+		}
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: missing value of %q", n, line)
+		}
+		v, rest, err := tomlString(strings.TrimSpace(line[i+1:]))
+		if err != nil || !tomlComment(rest) {
+			return nil, fmt.Errorf("line %d: bad string value %q", n, line[i+1:])
+		}
+		sites[table][strings.ToLower(tomlKey(line[:i]))] = v
+	}
+	return sites, scanner.Err()
+}
+
+// tomlKey returns the bare or quoted TOML key k
+func tomlKey(k string) string {
+	k = strings.TrimSpace(k)
+	if v, rest, err := tomlString(k); err == nil && len(rest) == 0 {
+		return v
+	}
+	return k
+}
+
+// tomlString parses the basic or literal TOML string at the start of s and
+// returns it and the rest of s
+func tomlString(s string) (string, string, error) {
+	if len(s) == 0 {
+		return "", "", io.ErrUnexpectedEOF
+	}
+	switch s[0] {
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", io.ErrUnexpectedEOF
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case '"':
+		// Find the closing quote, skipping escapes
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				v, err := strconv.Unquote(s[:i+1])
+				return v, s[i+1:], err
+			}
+		}
+		return "", "", io.ErrUnexpectedEOF
+	}
+	return "", "", strconv.ErrSyntax
+}
+
+// tomlComment reports whether s is empty or a comment
+func tomlComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return len(s) == 0 || s[0] == '#'
+}
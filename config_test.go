@@ -0,0 +1,188 @@
+package rego
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testINI = `; praw.ini
+[DEFAULT]
+user_agent=linux:rego.test:v1.0 (by /u/rego_dev)
+
+[bot]
+username = bot_account
+password: se=cret:x
+client_id=abc
+# comment
+[other]
+user_agent = windows:other:v2 (by /u/other)
+`
+
+const testTOML = `# rego.toml
+user_agent = "linux:rego.test:v1.0 (by /u/rego_dev)"
+
+[bot]
+username = 'bot_account'
+password = "se=cret:x\t#" # comment
+"client_id" = "abc"
+
+[ "other" ]
+user_agent = "windows:other:v2 (by /u/other)"
+`
+
+func Test_parseConfig(t *testing.T) {
+	want := map[string]map[string]string{
+		DefaultSite: {"user_agent": "linux:rego.test:v1.0 (by /u/rego_dev)"},
+		"bot":       {"username": "bot_account", "password": "se=cret:x", "client_id": "abc"},
+		"other":     {"user_agent": "windows:other:v2 (by /u/other)"},
+	}
+
+	sites, err := parseINI(strings.NewReader(testINI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sites, want) {
+		t.Errorf("INI: Got %v, wanted %v", sites, want)
+	}
+
+	want["bot"]["password"] = "se=cret:x\t#"
+	sites, err = parseTOML(strings.NewReader(testTOML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sites, want) {
+		t.Errorf("TOML: Got %v, wanted %v", sites, want)
+	}
+
+	for _, bad := range []string{"[bot", "key", `key = "open`, "key = bare", `key = "x" y`} {
+		if _, err := parseTOML(strings.NewReader(bad)); err == nil {
+			t.Errorf("TOML: Got no error for %q", bad)
+		}
+	}
+	for _, bad := range []string{"[bot", "key"} {
+		if _, err := parseINI(strings.NewReader(bad)); err == nil {
+			t.Errorf("INI: Got no error for %q", bad)
+		}
+	}
+}
+
+func Test_LoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	var tests = []struct {
+		name     string
+		data     string
+		password string
+	}{
+		{"praw.ini", testINI, "se=cret:x"},
+		{"rego.toml", testTOML, "se=cret:x\t#"},
+	}
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name)
+		err := os.WriteFile(path, []byte(test.data), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("REGO_CONFIG", path)
+
+		c, err := LoadConfig("bot")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Config{
+			ClientID:  "abc",
+			Password:  test.password,
+			Site:      "bot",
+			UserAgent: "linux:rego.test:v1.0 (by /u/rego_dev)",
+			Username:  "bot_account",
+		}
+		if *c != want {
+			t.Errorf("%s: Got %+v, wanted %+v", test.name, *c, want)
+		}
+
+		_, err = LoadConfig("nosuchsite")
+		if !errors.Is(err, ErrSiteNotFound) {
+			t.Errorf("%s: Got %v, wanted ErrSiteNotFound", test.name, err)
+		}
+	}
+
+	// Environment overrides, REGO_ taking precedence
+	t.Setenv("praw_site", "other")
+	t.Setenv("praw_username", "praw_user")
+	t.Setenv("praw_password", "praw_pw")
+	t.Setenv("REGO_PASSWORD", "rego_pw")
+	c, err := LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Site != "other" || c.Username != "praw_user" || c.Password != "rego_pw" || c.UserAgent != "windows:other:v2 (by /u/other)" {
+		t.Errorf("Got %+v, wanted the environment applied", *c)
+	}
+
+	// An explicit site takes precedence over praw_site and REGO_SITE
+	t.Setenv("REGO_SITE", "other")
+	c, err = LoadConfig("bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Site != "bot" || c.ClientID != "abc" || c.UserAgent != "linux:rego.test:v1.0 (by /u/rego_dev)" {
+		t.Errorf("Got %+v, wanted site bot", *c)
+	}
+
+	// Only the environment
+	t.Setenv("REGO_CONFIG", filepath.Join(dir, "missing.ini"))
+	_, err = LoadConfig("")
+	if !os.IsNotExist(err) {
+		t.Errorf("Got %v, wanted the missing file reported", err)
+	}
+}
+
+func Test_NewSessionFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "praw.ini")
+	err := os.WriteFile(path, []byte("[DEFAULT]\nuser_agent=RegoBot/1.0\n[ok]\nuser_agent=linux:rego.test:v1.0 (by /u/rego_dev)\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("REGO_CONFIG", path)
+
+	_, err = NewSessionFromConfig("")
+	if !errors.Is(err, ErrUserAgent) {
+		t.Errorf("Got %v, wanted ErrUserAgent", err)
+	}
+	s, err := NewSessionFromConfig("ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.useragent != "linux:rego.test:v1.0 (by /u/rego_dev)" || len(s.Cookie()) > 0 {
+		t.Errorf("Got %q, wanted an unauthenticated session with the configured user agent", s.useragent)
+	}
+}
+
+func Test_ValidateUserAgent(t *testing.T) {
+	var tests = []struct {
+		ua    string
+		valid bool
+	}{
+		{"linux:com.example.bot:v1.0 (by /u/example)", true},
+		{"android:com.example.app:1.2.3 (by u/Some_User-1)", true},
+		{"RegoBot/1.0", false},
+		{"", false},
+		{"linux:com.example.bot:v1.0", false},
+		{"linux:com.example.bot:v1.0 (by /u/ab)", false},
+		{"Go-http-client/1.1", false},
+		{"linux:bot v1:1.0 (by /u/example)", false},
+	}
+
+	for _, test := range tests {
+		err := ValidateUserAgent(test.ua)
+		if (err == nil) != test.valid {
+			t.Errorf("Got %v for %q, wanted valid %t", err, test.ua, test.valid)
+		}
+		if err != nil && !errors.Is(err, ErrUserAgent) {
+			t.Errorf("Got %v, wanted ErrUserAgent", err)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package rego
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// MaxFlairCSV is the max number of rows Reddit accepts per flair CSV upload
+	MaxFlairCSV = 100
+
+	// MaxFlairLimit is the upper maximum limit for Session.FlairList() requests
+	MaxFlairLimit = 1000
+)
+
+var (
+	ErrFlairTarget  = errors.New("flair selection needs exactly one of link and user")
+	ErrNoTemplateID = errors.New("missing flair template id")
+)
+
+// FlairType selects between user and link flair templates
+type FlairType string
+
+// Flair template types
+const (
+	FlairUser FlairType = "USER_FLAIR"
+	FlairLink FlairType = "LINK_FLAIR"
+)
+
+// FlairTemplate represents a user or link flair template of a subreddit
+type FlairTemplate struct {
+	AllowableContent string                     `json:"allowable_content,omitempty"` // "all", "emoji" or "text"
+	BackgroundColor  string                     `json:"background_color"`            // Hex color, e.g. "#dadada"
+	CSSClass         string                     `json:"css_class"`                   // CSS class of the flair
+	Extra            map[string]json.RawMessage `json:"-"`                           // Fields not decoded into the struct, kept when encoding
+	ID               string                     `json:"id"`                          // Template identifier
+	MaxEmojis        int                        `json:"max_emojis,omitempty"`        // Max number of emojis allowed
+	ModOnly          bool                       `json:"mod_only"`                    // True if only moderators may select the flair
+	Text             string                     `json:"text"`                        // Flair text
+	TextColor        string                     `json:"text_color"`                  // "light" or "dark"
+	TextEditable     bool                       `json:"text_editable"`               // True if users may edit the flair text
+	Type             string                     `json:"type"`                        // "text" or "richtext"
+}
+
+// UnmarshalJSON decodes a flair template, unknown fields are kept in Extra
+func (f *FlairTemplate) UnmarshalJSON(b []byte) error {
+	type template FlairTemplate
+	extra, err := unmarshalExtra(b, (*template)(f))
+	if err != nil {
+		return err
+	}
+	f.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the flair template as sent by Reddit, including Extra fields
+func (f FlairTemplate) MarshalJSON() ([]byte, error) {
+	type template FlairTemplate
+	return marshalExtra((*template)(&f), f.Extra)
+}
+
+// UserFlair represents the flair assigned to a user of a subreddit
+type UserFlair struct {
+	CSSClass string `json:"flair_css_class"` // CSS class of the flair
+	Text     string `json:"flair_text"`      // Flair text
+	User     string `json:"user"`            // Account name of the user
+}
+
+// FlairSelection describes a flair template selection made with
+// Session.SelectFlair. Exactly one of Link and User must be set.
+type FlairSelection struct {
+	Link       Fullname // Fullname of the link to flair, e.g. "t3_c3v7f8u"
+	TemplateID string   // Flair template identifier
+	Text       string   // Flair text, only used if the template is text editable
+	User       string   // Account name of the user to flair
+}
+
+// FlairCSVRow is a single row of a bulk flair upload. Leaving both Text
+// and CSSClass empty clears the flair of the user.
+type FlairCSVRow struct {
+	CSSClass string
+	Text     string
+	User     string
+}
+
+// FlairCSVResult is the per-row result of a bulk flair upload
+type FlairCSVResult struct {
+	Errors   map[string]string `json:"errors"`   // Errors keyed by column name
+	OK       bool              `json:"ok"`       // True if the row was applied
+	Status   string            `json:"status"`   // Human readable status, e.g. "added flair for user x"
+	Warnings map[string]string `json:"warnings"` // Warnings keyed by column name
+}
+
+// SetUserFlair sets the flair text and CSS class of user u in subreddit sub.
+// Leaving both text and class empty clears the flair.
+func (s *Session) SetUserFlair(sub string, u string, text string, class string) error {
+	v := url.Values{}
+	v.Set("name", u)
+	v.Set("text", text)
+	v.Set("css_class", class)
+
+	_, err := s.api(s.url(fmt.Sprintf(apiFlair, sub)), v)
+	return err
+}
+
+// DeleteUserFlair clears the flair of user u in subreddit sub
+func (s *Session) DeleteUserFlair(sub string, u string) error {
+	v := url.Values{}
+	v.Set("name", u)
+
+	_, err := s.api(s.url(fmt.Sprintf(apiFlairDelete, sub)), v)
+	return err
+}
+
+// SetLinkFlair sets the flair text and CSS class of link l
+func (s *Session) SetLinkFlair(sub string, l Fullname, text string, class string) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+	v := url.Values{}
+	v.Set("link", string(l))
+	v.Set("text", text)
+	v.Set("css_class", class)
+
+	_, err := s.api(s.url(fmt.Sprintf(apiFlair, sub)), v)
+	return err
+}
+
+// SelectFlair assigns a flair template to a user or a link. ErrFlairTarget
+// is returned unless exactly one of f.Link and f.User is set, ErrNoTemplateID
+// if f.TemplateID is empty.
+func (s *Session) SelectFlair(sub string, f FlairSelection) error {
+	if (len(f.Link) > 0) == (len(f.User) > 0) {
+		return ErrFlairTarget
+	}
+	if len(f.TemplateID) == 0 {
+		return ErrNoTemplateID
+	}
+
+	v := url.Values{}
+	v.Set("flair_template_id", f.TemplateID)
+	if len(f.Link) > 0 {
+		if err := f.Link.Validate(); err != nil {
+			return err
+		}
+		v.Set("link", string(f.Link))
+	}
+	if len(f.User) > 0 {
+		v.Set("name", f.User)
+	}
+	if len(f.Text) > 0 {
+		v.Set("text", f.Text)
+	}
+
+	_, err := s.api(s.url(fmt.Sprintf(apiFlairSelect, sub)), v)
+	return err
+}
+
+// FlairCSV sets user flair in bulk. Rows are uploaded in batches of
+// MaxFlairCSV and one result is returned for each row, in order.
+//
+// On error the results of all previously uploaded batches are returned.
+func (s *Session) FlairCSV(sub string, rows []FlairCSVRow) ([]FlairCSVResult, error) {
+	u := s.url(fmt.Sprintf(apiFlairCSV, sub))
+
+	var results []FlairCSVResult
+	for len(rows) > 0 {
+		n := len(rows)
+		if n > MaxFlairCSV {
+			n = MaxFlairCSV
+		}
+
+		buf := bytes.Buffer{}
+		w := csv.NewWriter(&buf)
+		for _, r := range rows[:n] {
+			w.Write([]string{r.User, r.Text, r.CSSClass})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return results, err
+		}
+
+		v := url.Values{}
+		v.Set("flair_csv", buf.String())
+
+		batch := []FlairCSVResult{}
+		err := s.call("POST", u, v, &batch)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, batch...)
+		rows = rows[n:]
+	}
+	return results, nil
+}
+
+// FlairTemplates returns the user or link flair templates of subreddit sub
+func (s *Session) FlairTemplates(sub string, t FlairType) ([]FlairTemplate, error) {
+	method := apiFlairTemplatesUser
+	if t == FlairLink {
+		method = apiFlairTemplatesLink
+	}
+
+	templates := []FlairTemplate{}
+	err := s.call("GET", s.url(fmt.Sprintf(method, sub)), nil, &templates)
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// CreateFlairTemplate creates a new flair template in subreddit sub.
+// The ID of ft is ignored, the created template is returned.
+func (s *Session) CreateFlairTemplate(sub string, t FlairType, ft FlairTemplate) (*FlairTemplate, error) {
+	ft.ID = ""
+	return s.flairTemplate(sub, t, ft)
+}
+
+// UpdateFlairTemplate updates the flair template identified by ft.ID
+func (s *Session) UpdateFlairTemplate(sub string, t FlairType, ft FlairTemplate) (*FlairTemplate, error) {
+	if len(ft.ID) == 0 {
+		return nil, ErrNoTemplateID
+	}
+	return s.flairTemplate(sub, t, ft)
+}
+
+// DeleteFlairTemplate deletes flair template id from subreddit sub
+func (s *Session) DeleteFlairTemplate(sub string, id string) error {
+	v := url.Values{}
+	v.Set("flair_template_id", id)
+
+	_, err := s.api(s.url(fmt.Sprintf(apiFlairTemplateDelete, sub)), v)
+	return err
+}
+
+// ClearFlairTemplates deletes all user or link flair templates of subreddit sub
+func (s *Session) ClearFlairTemplates(sub string, t FlairType) error {
+	v := url.Values{}
+	v.Set("flair_type", string(t))
+
+	_, err := s.api(s.url(fmt.Sprintf(apiFlairTemplateClear, sub)), v)
+	return err
+}
+
+func (s *Session) flairTemplate(sub string, t FlairType, ft FlairTemplate) (*FlairTemplate, error) {
+	v := url.Values{}
+	v.Set("flair_type", string(t))
+	if len(ft.ID) > 0 {
+		v.Set("flair_template_id", ft.ID)
+	}
+	if len(ft.AllowableContent) > 0 {
+		v.Set("allowable_content", ft.AllowableContent)
+	}
+	if len(ft.BackgroundColor) > 0 {
+		v.Set("background_color", ft.BackgroundColor)
+	}
+	if ft.MaxEmojis > 0 {
+		v.Set("max_emojis", strconv.Itoa(ft.MaxEmojis))
+	}
+	if len(ft.TextColor) > 0 {
+		v.Set("text_color", ft.TextColor)
+	}
+	v.Set("css_class", ft.CSSClass)
+	v.Set("mod_only", strconv.FormatBool(ft.ModOnly))
+	v.Set("text", ft.Text)
+	v.Set("text_editable", strconv.FormatBool(ft.TextEditable))
+
+	reply := FlairTemplate{}
+	err := s.call("POST", s.url(fmt.Sprintf(apiFlairTemplate, sub)), v, &reply)
+	if err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// FlairList returns a paginated list of user flair in subreddit sub
+// wrapped in a FlairPage type.
+func (s *Session) FlairList(sub string) *FlairPage {
+	p := FlairPage{}
+	p.s = s
+	p.url = s.url(fmt.Sprintf(apiFlairList, sub))
+	return &p
+}
+
+// FlairPage paginates the user flair list of a subreddit
+type FlairPage struct {
+	s     *Session
+	url   string
+	next  string // Reference for the following page
+	prev  string // Reference for the preceding page
+	end   bool   // True when there is no following page
+	limit int    // Limit of items returned
+}
+
+// Next returns the following set of user flair. An empty
+// result is returned when the list is exhausted.
+func (p *FlairPage) Next() ([]UserFlair, error) {
+	if p.end {
+		return nil, nil
+	}
+	v := p.values()
+	if len(p.next) > 0 {
+		v.Set("after", p.next)
+	}
+	return p.list(v)
+}
+
+// Previous returns the preceding set of user flair
+func (p *FlairPage) Previous() ([]UserFlair, error) {
+	v := p.values()
+	if len(p.prev) > 0 {
+		v.Set("before", p.prev)
+	}
+	return p.list(v)
+}
+
+// SetLimit sets the max number of users returned from calls to Previous and Next
+func (p *FlairPage) SetLimit(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	if limit > MaxFlairLimit {
+		limit = MaxFlairLimit
+	}
+	p.limit = limit
+}
+
+func (p *FlairPage) list(v url.Values) ([]UserFlair, error) {
+	reply := struct {
+		Next  string      `json:"next"`
+		Prev  string      `json:"prev"`
+		Users []UserFlair `json:"users"`
+	}{}
+	err := p.s.call("GET", p.url, v, &reply)
+	if err != nil {
+		return nil, err
+	}
+
+	p.next = reply.Next
+	p.prev = reply.Prev
+	p.end = len(reply.Next) == 0
+	return reply.Users, nil
+}
+
+func (p *FlairPage) values() url.Values {
+	v := url.Values{}
+	if p.limit > 0 {
+		v.Set("limit", fmt.Sprintf("%d", p.limit))
+	}
+	return v
+}
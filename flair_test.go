@@ -0,0 +1,110 @@
+package rego
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFlairCSV(t *testing.T) {
+	var batches []int
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/r/test/api/flaircsv" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		records, err := csv.NewReader(strings.NewReader(r.FormValue("flair_csv"))).ReadAll()
+		if err != nil {
+			t.Error(err)
+		}
+		batches = append(batches, len(records))
+		results := []FlairCSVResult{}
+		for _, rec := range records {
+			results = append(results, FlairCSVResult{OK: true, Status: "added flair for user " + rec[0]})
+		}
+		json.NewEncoder(w).Encode(results)
+	}))
+	defer ts.Close()
+
+	var rows []FlairCSVRow
+	for i := 0; i < 150; i++ {
+		rows = append(rows, FlairCSVRow{User: fmt.Sprintf("user%d", i), Text: "text, with comma"})
+	}
+	results, err := s.FlairCSV("test", rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 2 || batches[0] != MaxFlairCSV || batches[1] != 50 {
+		t.Errorf("Got batches %v, wanted [100 50]", batches)
+	}
+	if len(results) != len(rows) {
+		t.Fatalf("Got %d results, wanted %d", len(results), len(rows))
+	}
+	if results[149].Status != "added flair for user user149" {
+		t.Errorf("Got status %q", results[149].Status)
+	}
+}
+
+func TestFlairList(t *testing.T) {
+	pages := map[string]string{
+		"":     `{"users": [{"user": "a", "flair_text": "A", "flair_css_class": "ca"}], "next": "t2_a"}`,
+		"t2_a": `{"users": [{"user": "b", "flair_text": "B", "flair_css_class": "cb"}], "prev": "t2_b"}`,
+	}
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("limit") != "1" {
+			t.Errorf("Got limit %q, wanted 1", r.FormValue("limit"))
+		}
+		fmt.Fprint(w, pages[r.FormValue("after")])
+	}))
+	defer ts.Close()
+
+	page := s.FlairList("test")
+	page.SetLimit(1)
+	for _, user := range []string{"a", "b"} {
+		flair, err := page.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(flair) != 1 || flair[0].User != user {
+			t.Errorf("Got %v, wanted user %s", flair, user)
+		}
+	}
+	flair, err := page.Next()
+	if err != nil || len(flair) != 0 {
+		t.Errorf("Got %v, %v, wanted exhausted list", flair, err)
+	}
+}
+
+func TestSelectFlair(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("flair_template_id") != "abc" || r.FormValue("name") != "user" {
+			t.Errorf("Unexpected form %v", r.Form)
+		}
+		fmt.Fprint(w, `{"json": {"errors": [["BAD_FLAIR_TARGET", "not a valid target", "name"]]}}`)
+	}))
+	defer ts.Close()
+
+	err := s.SelectFlair("test", FlairSelection{TemplateID: "abc", User: "user"})
+	if _, ok := err.(APIError); !ok {
+		t.Errorf("Got %v, wanted APIError", err)
+	}
+
+	// Invalid selections fail without a request
+	var tests = []struct {
+		f   FlairSelection
+		err error
+	}{
+		{FlairSelection{TemplateID: "abc"}, ErrFlairTarget},
+		{FlairSelection{TemplateID: "abc", Link: "t3_abc", User: "user"}, ErrFlairTarget},
+		{FlairSelection{User: "user"}, ErrNoTemplateID},
+		{FlairSelection{Link: "t3_abc", Text: "text"}, ErrNoTemplateID},
+	}
+	for _, test := range tests {
+		err := s.SelectFlair("test", test.f)
+		if err != test.err {
+			t.Errorf("Got %v for %+v, wanted %v", err, test.f, test.err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package rego
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrBadFullname = errors.New("malformed fullname")
+)
+
+// Fullname is the kind prefixed identifier of a Thing, e.g. "t3_c3v7f8u"
+// where "t3" is the kind and "c3v7f8u" the base36 encoded ID.
+type Fullname string
+
+// NewFullname returns the fullname of the Thing of the given kind and ID
+func NewFullname(kind string, id string) Fullname {
+	return Fullname(kind + "_" + id)
+}
+
+// FullnameFromInt returns the fullname of the Thing of the given kind and numeric ID
+func FullnameFromInt(kind string, id uint64) Fullname {
+	return NewFullname(kind, strconv.FormatUint(id, 36))
+}
+
+// ParseFullname parses and validates fullname s
+func ParseFullname(s string) (Fullname, error) {
+	f := Fullname(s)
+	return f, f.Validate()
+}
+
+// Kind returns the kind of the fullname, e.g. "t3"
+func (f Fullname) Kind() string {
+	kind, _, _ := strings.Cut(string(f), "_")
+	return kind
+}
+
+// ID returns the base36 encoded ID of the fullname, e.g. "c3v7f8u"
+func (f Fullname) ID() string {
+	_, id, _ := strings.Cut(string(f), "_")
+	return id
+}
+
+// Int returns the numeric value of the base36 encoded ID
+func (f Fullname) Int() (uint64, error) {
+	n, err := strconv.ParseUint(f.ID(), 36, 64)
+	if err != nil {
+		return 0, ErrBadFullname
+	}
+	return n, nil
+}
+
+// Validate returns ErrBadFullname unless the fullname has a Thing kind,
+// t1 to t8, and a lowercase base36 ID
+func (f Fullname) Validate() error {
+	kind, id, ok := strings.Cut(string(f), "_")
+	if !ok || len(kind) != 2 || kind[0] != 't' || kind[1] < '1' || kind[1] > '8' || len(id) == 0 {
+		return ErrBadFullname
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'z') {
+			return ErrBadFullname
+		}
+	}
+	return nil
+}
+
+// String returns the fullname as a string
+func (f Fullname) String() string {
+	return string(f)
+}
+
+// Fullname returns the fullname of the account
+func (a *Account) Fullname() Fullname {
+	return NewFullname(TypeAccount, a.ID)
+}
+
+// Fullname returns the fullname of the link
+func (l *Link) Fullname() Fullname {
+	if len(l.Name) > 0 {
+		return Fullname(l.Name)
+	}
+	return NewFullname(TypeLink, l.ID)
+}
+
+// Fullname returns the fullname of the comment
+func (c *Comment) Fullname() Fullname {
+	if len(c.Name) > 0 {
+		return Fullname(c.Name)
+	}
+	return NewFullname(TypeComment, c.ID)
+}
+
+// Link returns the fullname of the link the comment is in
+func (c *Comment) Link() Fullname {
+	return Fullname(c.LinkID)
+}
+
+// Parent returns the fullname of the comment or link the comment is a reply to
+func (c *Comment) Parent() Fullname {
+	return Fullname(c.ParentID)
+}
@@ -0,0 +1,68 @@
+package rego
+
+import (
+	"testing"
+)
+
+func TestParseFullname(t *testing.T) {
+	var tests = []struct {
+		fullname string
+		valid    bool
+		kind     string
+		id       string
+		n        uint64
+	}{
+		{"t3_c3v7f8u", true, "t3", "c3v7f8u", 26355201006},
+		{"t1_0", true, "t1", "0", 0},
+		{"t2_zz", true, "t2", "zz", 1295},
+		{"c3v7f8u", false, "c3v7f8u", "", 0},
+		{"t9_abc", false, "t9", "abc", 13368},
+		{"t3_", false, "t3", "", 0},
+		{"t3_ABC", false, "t3", "ABC", 13368},
+		{"LiveUpdate_abc", false, "LiveUpdate", "abc", 13368},
+	}
+
+	for _, test := range tests {
+		f, err := ParseFullname(test.fullname)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: got error %v, wanted valid %t", test.fullname, err, test.valid)
+		}
+		if f.Kind() != test.kind || f.ID() != test.id {
+			t.Errorf("%s: got %s, %s, wanted %s, %s", test.fullname, f.Kind(), f.ID(), test.kind, test.id)
+		}
+		if n, _ := f.Int(); n != test.n {
+			t.Errorf("%s: got %d, wanted %d", test.fullname, n, test.n)
+		}
+	}
+}
+
+func TestFullnameFromInt(t *testing.T) {
+	if f := FullnameFromInt(TypeLink, 26355201006); f != "t3_c3v7f8u" {
+		t.Errorf("Got %s, wanted t3_c3v7f8u", f)
+	}
+}
+
+func TestThingFullname(t *testing.T) {
+	var tests = []struct {
+		fullname Fullname
+		want     Fullname
+	}{
+		{(&Comment{ID: "c3v7f8u"}).Fullname(), "t1_c3v7f8u"},
+		{(&Comment{ID: "c3v7f8u", Name: "t1_c3v7f8u"}).Fullname(), "t1_c3v7f8u"},
+		{(&Link{ID: "c3v7f8u"}).Fullname(), "t3_c3v7f8u"},
+		{(&Account{ID: "c3v7f8u"}).Fullname(), "t2_c3v7f8u"},
+	}
+
+	for _, test := range tests {
+		if test.fullname != test.want {
+			t.Errorf("Got %s, wanted %s", test.fullname, test.want)
+		}
+	}
+}
+
+func TestCommentBadFullname(t *testing.T) {
+	s := NewSession("RegoTest/1.0")
+	if _, err := s.Comment("c3v7f8u", "text"); err != ErrBadFullname {
+		t.Errorf("Got %v, wanted ErrBadFullname", err)
+	}
+}
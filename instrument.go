@@ -0,0 +1,274 @@
+package rego
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Instrumentation receives the requests of a session, e.g. to record
+// metrics or traces. Each attempt of a request, including retries by
+// middleware, is reported once. Implementations must be safe for concurrent
+// use.
+type Instrumentation interface {
+	// StartRequest is called before sending a request and returns the
+	// context the request is sent with
+	StartRequest(ctx context.Context, r RequestInfo) context.Context
+	// EndRequest is called with the context returned by StartRequest when
+	// the reply has been received or the request failed
+	EndRequest(ctx context.Context, r RequestInfo, res RequestResult)
+}
+
+// RequestInfo describes an instrumented request
+type RequestInfo struct {
+	Attempt  int             // Attempt number, counting from 1, see WithAttempt
+	Endpoint string          // API endpoint template, e.g. "/user/%s/about.json"
+	Method   string          // HTTP method
+	Previous context.Context // Context StartRequest returned for the previous attempt, nil for the first
+}
+
+// RequestResult is the result of an instrumented request
+type RequestResult struct {
+	Duration  time.Duration // Time until the reply headers were received or the request failed
+	Err       error         // Error sending the request, if any
+	Remaining int           // Remaining rate limit, -1 if not reported
+	Status    int           // HTTP status code, 0 if Err is set
+}
+
+// SetInstrumentation makes the session report its requests to i, or stops
+// reporting them if i is nil
+func (s *Session) SetInstrumentation(i Instrumentation) {
+	s.instrumentation = i
+}
+
+// observe sends req using the HTTP client, logging and instrumenting it. It
+// is the innermost Doer of the pipeline to have every attempt observed.
+func (s *Session) observe(req *http.Request) (*http.Response, error) {
+	info := RequestInfo{
+		Attempt:  attempt(req),
+		Endpoint: endpointTemplate(req.URL.Path),
+		Method:   req.Method,
+	}
+	ctx := req.Context()
+	if s.instrumentation != nil {
+		a, _ := ctx.Value(attemptsKey{}).(*attempts)
+		if a != nil && info.Attempt > 1 {
+			info.Previous = a.last()
+		}
+		ctx = s.instrumentation.StartRequest(ctx, info)
+		req = req.WithContext(ctx)
+		if a != nil {
+			a.set(ctx)
+		}
+	}
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	d := time.Since(start)
+	s.logRequest(req, resp, err, d)
+
+	if s.instrumentation != nil {
+		res := RequestResult{Duration: d, Err: err, Remaining: -1}
+		if err == nil {
+			res.Status = resp.StatusCode
+			if r, err := strconv.Atoi(resp.Header.Get("X-Ratelimit-Remaining")); err == nil {
+				res.Remaining = r
+			}
+		}
+		s.instrumentation.EndRequest(ctx, info, res)
+	}
+	return resp, err
+}
+
+// attemptsKey is the context key of the attempts of a request
+type attemptsKey struct{}
+
+// attempts tracks the instrumented context of the last attempt of a request,
+// shared by its retries
+type attempts struct {
+	ctx  context.Context
+	lock sync.Mutex
+}
+
+// withAttempts returns req tracking its attempts
+func withAttempts(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), attemptsKey{}, &attempts{}))
+}
+
+// last returns the instrumented context of the last attempt, nil if none
+func (a *attempts) last() context.Context {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.ctx
+}
+
+// set records ctx as the instrumented context of the last attempt
+func (a *attempts) set(ctx context.Context) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.ctx = ctx
+}
+
+// DefaultBuckets are the request duration histogram buckets, in seconds,
+// used by NewMetrics
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics is an Instrumentation collecting request metrics, exposed in the
+// Prometheus text format by WriteTo and ServeHTTP:
+//
+//	rego_requests_total{endpoint,method,status}       Requests sent
+//	rego_request_duration_seconds{endpoint,method}    Request latency histogram, including failed requests
+//	rego_ratelimit_remaining                          Remaining rate limit
+//	rego_retries_total{endpoint,method}               Retried requests
+//	rego_errors_total{endpoint,method,type}           Failed requests, by type "transport" or "status"
+type Metrics struct {
+	buckets   []float64
+	durations map[string]*histogram // By formatted labels
+	errors    map[string]int
+	lock      sync.Mutex
+	remaining float64
+	requests  map[string]int
+	retries   map[string]int
+}
+
+// histogram is a cumulative latency histogram
+type histogram struct {
+	counts []int // Per bucket, plus +Inf
+	sum    float64
+}
+
+// NewMetrics returns empty Metrics using DefaultBuckets
+func NewMetrics() *Metrics {
+	return &Metrics{
+		buckets:   DefaultBuckets,
+		durations: map[string]*histogram{},
+		errors:    map[string]int{},
+		remaining: math.NaN(),
+		requests:  map[string]int{},
+		retries:   map[string]int{},
+	}
+}
+
+// StartRequest implements Instrumentation
+func (m *Metrics) StartRequest(ctx context.Context, r RequestInfo) context.Context {
+	return ctx
+}
+
+// EndRequest implements Instrumentation
+func (m *Metrics) EndRequest(ctx context.Context, r RequestInfo, res RequestResult) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := labels("endpoint", r.Endpoint, "method", r.Method)
+	if r.Attempt > 1 {
+		m.retries[key]++
+	}
+	if res.Err != nil {
+		m.errors[key+","+labels("type", "transport")]++
+	} else {
+		if res.Status >= 400 {
+			m.errors[key+","+labels("type", "status")]++
+		}
+		m.requests[key+","+labels("status", strconv.Itoa(res.Status))]++
+	}
+	if res.Remaining >= 0 {
+		m.remaining = float64(res.Remaining)
+	}
+
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]int, len(m.buckets)+1)}
+		m.durations[key] = h
+	}
+	sec := res.Duration.Seconds()
+	h.sum += sec
+	for i, b := range m.buckets {
+		if sec <= b {
+			h.counts[i]++
+		}
+	}
+	h.counts[len(m.buckets)]++
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	b := strings.Builder{}
+	b.WriteString("# HELP rego_requests_total Reddit API requests sent.\n")
+	b.WriteString("# TYPE rego_requests_total counter\n")
+	for _, k := range sortedKeys(m.requests) {
+		fmt.Fprintf(&b, "rego_requests_total{%s} %d\n", k, m.requests[k])
+	}
+
+	b.WriteString("# HELP rego_request_duration_seconds Reddit API request latency.\n")
+	b.WriteString("# TYPE rego_request_duration_seconds histogram\n")
+	keys := make([]string, 0, len(m.durations))
+	for k := range m.durations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		h := m.durations[k]
+		for i, le := range m.buckets {
+			fmt.Fprintf(&b, "rego_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", k, strconv.FormatFloat(le, 'g', -1, 64), h.counts[i])
+		}
+		n := h.counts[len(m.buckets)]
+		fmt.Fprintf(&b, "rego_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", k, n)
+		fmt.Fprintf(&b, "rego_request_duration_seconds_sum{%s} %s\n", k, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "rego_request_duration_seconds_count{%s} %d\n", k, n)
+	}
+
+	b.WriteString("# HELP rego_ratelimit_remaining Remaining Reddit API rate limit.\n")
+	b.WriteString("# TYPE rego_ratelimit_remaining gauge\n")
+	fmt.Fprintf(&b, "rego_ratelimit_remaining %s\n", strconv.FormatFloat(m.remaining, 'g', -1, 64))
+
+	b.WriteString("# HELP rego_retries_total Reddit API requests retried.\n")
+	b.WriteString("# TYPE rego_retries_total counter\n")
+	for _, k := range sortedKeys(m.retries) {
+		fmt.Fprintf(&b, "rego_retries_total{%s} %d\n", k, m.retries[k])
+	}
+
+	b.WriteString("# HELP rego_errors_total Reddit API requests failed.\n")
+	b.WriteString("# TYPE rego_errors_total counter\n")
+	for _, k := range sortedKeys(m.errors) {
+		fmt.Fprintf(&b, "rego_errors_total{%s} %d\n", k, m.errors[k])
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
+
+// labels formats Prometheus label pairs kv, escaping the values
+func labels(kv ...string) string {
+	var pairs []string
+	for i := 0; i < len(kv); i += 2 {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(kv[i+1])
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, kv[i], v))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// sortedKeys returns the keys of counters m in order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
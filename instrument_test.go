@@ -0,0 +1,78 @@
+package rego
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Metrics(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining", "99")
+		switch r.URL.Path {
+		case "/user/bob/about.json":
+			fmt.Fprint(w, `{"kind": "t2", "data": {"name": "bob"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	m := NewMetrics()
+	s.SetInstrumentation(m)
+	s.User("bob")
+	s.User("bob")
+	s.Subreddit("nosuchsub")
+
+	// Direct calls of a retry and transport error
+	ctx := WithAttempt(context.Background(), 2)
+	info := RequestInfo{Attempt: 2, Endpoint: apiMe, Method: "GET"}
+	m.EndRequest(m.StartRequest(ctx, info), info, RequestResult{Duration: 30 * time.Second, Err: errors.New("timeout"), Remaining: -1})
+
+	buf := bytes.Buffer{}
+	_, err := m.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`rego_requests_total{endpoint="/r/%s/about.json",method="GET",status="404"} 1`,
+		`rego_requests_total{endpoint="/user/%s/about.json",method="GET",status="200"} 2`,
+		`rego_request_duration_seconds_bucket{endpoint="/user/%s/about.json",method="GET",le="+Inf"} 2`,
+		`rego_request_duration_seconds_count{endpoint="/user/%s/about.json",method="GET"} 2`,
+		`rego_ratelimit_remaining 99`,
+		`rego_retries_total{endpoint="/api/me.json",method="GET"} 1`,
+		`rego_errors_total{endpoint="/api/me.json",method="GET",type="transport"} 1`,
+		`rego_request_duration_seconds_sum{endpoint="/api/me.json",method="GET"} 30`,
+		`rego_errors_total{endpoint="/r/%s/about.json",method="GET",type="status"} 1`,
+		`# TYPE rego_request_duration_seconds histogram`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("Metrics lack %s", want)
+		}
+	}
+}
+
+func Test_labels(t *testing.T) {
+	if l := labels("a", `x"y\z`, "b", "1\n2"); l != `a="x\"y\\z",b="1\n2"` {
+		t.Errorf("Got %s", l)
+	}
+}
+
+func Test_histogram(t *testing.T) {
+	m := NewMetrics()
+	info := RequestInfo{Attempt: 1, Endpoint: apiMe, Method: "GET"}
+	for _, d := range []time.Duration{20 * time.Millisecond, 300 * time.Millisecond, 20 * time.Second} {
+		m.EndRequest(context.Background(), info, RequestResult{Duration: d, Remaining: -1, Status: 200})
+	}
+	h := m.durations[labels("endpoint", apiMe, "method", "GET")]
+	want := []int{1, 1, 1, 2, 2, 2, 2, 2, 3}
+	if fmt.Sprint(h.counts) != fmt.Sprint(want) {
+		t.Errorf("Got buckets %v, wanted %v", h.counts, want)
+	}
+}
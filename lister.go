@@ -2,16 +2,84 @@ package rego
 
 import (
 	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
 )
 
+// DecodeFunc decodes the data structure of a Thing into a typed item
+type DecodeFunc func(json.RawMessage) (interface{}, error)
+
+var (
+	kindsLock sync.RWMutex
+	kinds     = map[string]DecodeFunc{
+		TypeComment:    decodeAs(Comment{}),
+		TypeAccount:    decodeAs(Account{}),
+		TypeLink:       decodeAs(Link{}),
+		TypeMessage:    decodeAs(Message{}),
+		TypeSubreddit:  decodeAs(Subreddit{}),
+		TypeAward:      decodeAs(Trophy{}),
+		TypePromo:      decodeAs(PromoCampaign{}),
+		TypeModAction:  decodeAs(ModAction{}),
+		TypeMore:       decodeAs(More{}),
+		TypeMulti:      decodeAs(Multireddit{}),
+		TypeLiveUpdate: decodeAs(LiveUpdate{}),
+	}
+)
+
+// RegisterKind registers the decoder used by Listing.Items and Listing.Decode
+// for Thing items of the given kind, replacing any previous decoder.
+func RegisterKind(kind string, f DecodeFunc) {
+	kindsLock.Lock()
+	defer kindsLock.Unlock()
+	kinds[kind] = f
+}
+
+// decodeAs returns a DecodeFunc decoding into values of the same type as v
+func decodeAs(v interface{}) DecodeFunc {
+	t := reflect.TypeOf(v)
+	return func(b json.RawMessage) (interface{}, error) {
+		item := reflect.New(t)
+		err := json.Unmarshal(b, item.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return item.Elem().Interface(), nil
+	}
+}
+
+func kindDecoder(kind string) DecodeFunc {
+	kindsLock.RLock()
+	defer kindsLock.RUnlock()
+	return kinds[kind]
+}
+
+// DecodeError reports a Thing item that failed to decode
+type DecodeError struct {
+	Err  error  // Underlying decoding error
+	Kind string // Kind of the item
+	Name string // Fullname, or other identifier, of the item if known
+}
+
+// Error returns a descriptive string of the error
+func (e DecodeError) Error() string {
+	return fmt.Sprintf("decoding %s %s: %s", e.Kind, e.Name, e.Err)
+}
+
+// Unwrap returns the underlying decoding error
+func (e DecodeError) Unwrap() error {
+	return e.Err
+}
+
 // The Lister interface wraps methods used to extract Thing items
 // from a Reddit Listing class.
 //
 // None of the methods return errors, as the data contained has already
 // been unmarshalled once. It is assumed that the data is syntactically
-// correct JSON and errors can safely be ignored.
+// correct JSON.
 //
-// Any malformed/unrecognised Thing item will be silently dropped.
+// Any malformed/unrecognised Thing item will be silently dropped, use
+// Listing.Decode to have decoding errors reported.
 type Lister interface {
 	Comments() []Comment
 	Items() []interface{}
@@ -35,37 +103,84 @@ func (l *Listing) Comments() []Comment {
 	var items []Comment
 	for _, c := range l.Data.Children {
 		if c.Kind == TypeComment {
-			item, _ := unmarshalComment(c.Data)
-			items = append(items, *item)
+			if item, err := unmarshalComment(c.Data); err == nil {
+				items = append(items, *item)
+			}
 		}
 	}
 	return items
 }
 
 // Items return a slice of interface{} items for cases where
-// the caller want to do type assertions.
+// the caller want to do type assertions. Items are decoded using
+// the decoder registered for their kind, see RegisterKind.
 func (l *Listing) Items() []interface{} {
 	var items []interface{}
 	for _, c := range l.Data.Children {
-		switch c.Kind {
-		case TypeComment:
-			item, _ := unmarshalComment(c.Data)
-			items = append(items, *item)
-		case TypeLink:
-			item, _ := unmarshalLink(c.Data)
-			items = append(items, *item)
+		f := kindDecoder(c.Kind)
+		if f == nil {
+			continue
+		}
+		if item, err := f(c.Data); err == nil {
+			items = append(items, item)
 		}
 	}
 	return items
 }
 
+// Decode returns the Listing items with Thing.Value set to the item decoded
+// using the decoder registered for its kind. Items of unregistered kinds are
+// returned with a nil Value. Decoding stops at the first item failing to
+// decode, returned as a DecodeError.
+func (l *Listing) Decode() ([]Thing, error) {
+	var things []Thing
+	for _, c := range l.Data.Children {
+		if f := kindDecoder(c.Kind); f != nil {
+			item, err := f(c.Data)
+			if err != nil {
+				return things, DecodeError{Err: err, Kind: c.Kind, Name: thingID(c)}
+			}
+			c.Value = item
+		}
+		things = append(things, c)
+	}
+	return things, nil
+}
+
 // Links return a slice of Link types
 func (l *Listing) Links() []Link {
 	var items []Link
 	for _, c := range l.Data.Children {
 		if c.Kind == TypeLink {
-			item, _ := unmarshalLink(c.Data)
-			items = append(items, *item)
+			if item, err := unmarshalLink(c.Data); err == nil {
+				items = append(items, *item)
+			}
+		}
+	}
+	return items
+}
+
+// LiveUpdates return a slice of LiveUpdate types
+func (l *Listing) LiveUpdates() []LiveUpdate {
+	var items []LiveUpdate
+	for _, c := range l.Data.Children {
+		if c.Kind == TypeLiveUpdate {
+			item := LiveUpdate{}
+			if json.Unmarshal(c.Data, &item) == nil {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// WikiRevisions return a slice of WikiRevision types
+func (l *Listing) WikiRevisions() []WikiRevision {
+	var items []WikiRevision
+	for _, c := range l.Data.Children {
+		item := WikiRevision{}
+		if json.Unmarshal(c.Data, &item) == nil && len(item.ID) > 0 {
+			items = append(items, item)
 		}
 	}
 	return items
@@ -0,0 +1,98 @@
+package rego
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testListing = `{"kind": "Listing", "data": {"children": [
+	{"kind": "t1", "data": {"name": "t1_a", "body": "comment"}},
+	{"kind": "t2", "data": {"name": "bob"}},
+	{"kind": "t3", "data": {"name": "t3_b", "title": "link"}},
+	{"kind": "t4", "data": {"name": "t4_c", "subject": "message"}},
+	{"kind": "t5", "data": {"name": "t5_d", "display_name": "golang"}},
+	{"kind": "more", "data": {"name": "t1_e", "count": 2, "children": ["f", "g"]}},
+	{"kind": "modaction", "data": {"id": "ModAction_h", "action": "removelink"}},
+	{"kind": "unknown", "data": {"name": "x_i"}}]}}`
+
+func TestListingItems(t *testing.T) {
+	list := Listing{}
+	err := json.Unmarshal([]byte(testListing), &list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := list.Items()
+	if len(items) != 7 {
+		t.Fatalf("Got %d items, wanted 7", len(items))
+	}
+	if c, ok := items[0].(Comment); !ok || c.Body != "comment" {
+		t.Errorf("Got %#v, wanted Comment", items[0])
+	}
+	if m, ok := items[5].(More); !ok || m.Count != 2 || len(m.Children) != 2 {
+		t.Errorf("Got %#v, wanted More", items[5])
+	}
+	if m, ok := items[6].(ModAction); !ok || m.Action != "removelink" {
+		t.Errorf("Got %#v, wanted ModAction", items[6])
+	}
+
+	things, err := list.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(things) != 8 || things[7].Value != nil {
+		t.Errorf("Got %d things, wanted 8 with the last undecoded", len(things))
+	}
+	if s, ok := things[4].Value.(Subreddit); !ok || s.DisplayName != "golang" {
+		t.Errorf("Got %#v, wanted Subreddit", things[4].Value)
+	}
+}
+
+func TestListingDecodeError(t *testing.T) {
+	list := Listing{}
+	err := json.Unmarshal([]byte(`{"kind": "Listing", "data": {"children": [
+		{"kind": "t3", "data": {"name": "t3_ok", "title": "link"}},
+		{"kind": "t3", "data": {"name": "t3_bad", "title": 42}}]}}`), &list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if links := list.Links(); len(links) != 1 {
+		t.Errorf("Got %d links, wanted 1", len(links))
+	}
+
+	things, err := list.Decode()
+	var decodeErr DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Name != "t3_bad" || decodeErr.Kind != TypeLink {
+		t.Errorf("Got %v, wanted DecodeError for t3_bad", err)
+	}
+	if len(things) != 1 {
+		t.Errorf("Got %d things decoded before failure, wanted 1", len(things))
+	}
+}
+
+func TestRegisterKind(t *testing.T) {
+	type custom struct {
+		Greeting string `json:"greeting"`
+	}
+	RegisterKind("custom", func(b json.RawMessage) (interface{}, error) {
+		c := custom{}
+		err := json.Unmarshal(b, &c)
+		c.Greeting = strings.ToUpper(c.Greeting)
+		return c, err
+	})
+	defer func() {
+		kindsLock.Lock()
+		delete(kinds, "custom")
+		kindsLock.Unlock()
+	}()
+
+	list := Listing{}
+	json.Unmarshal([]byte(`{"kind": "Listing", "data": {"children": [{"kind": "custom", "data": {"greeting": "hi"}}]}}`), &list)
+	items := list.Items()
+	if len(items) != 1 || items[0].(custom).Greeting != "HI" {
+		t.Errorf("Got %#v", items)
+	}
+}
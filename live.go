@@ -0,0 +1,338 @@
+package rego
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Live thread contributor permissions
+const (
+	LivePermAll         = "all"
+	LivePermClose       = "close"
+	LivePermDiscussions = "discussions"
+	LivePermEdit        = "edit"
+	LivePermManage      = "manage"
+	LivePermSettings    = "settings"
+	LivePermUpdate      = "update"
+)
+
+const (
+	livePrefixUpdate    = "LiveUpdate_"
+	liveStateLive       = "live"
+	liveTypeContributor = "liveupdate_contributor"
+	liveTypeInvitation  = "liveupdate_contributor_invite"
+)
+
+// LiveThread represents a Reddit Live event thread
+type LiveThread struct {
+	AnnouncementURL   string                     `json:"announcement_url"`    //
+	DescriptionHTML   string                     `json:"description_html"`    // Formatted HTML description
+	Description       string                     `json:"description"`         // Raw markdown description
+	Extra             map[string]json.RawMessage `json:"-"`                   // Fields not decoded into the struct, kept when encoding
+	ID                string                     `json:"id"`                  // Thread identifier, as used in /live/{id}
+	IsAnnouncement    bool                       `json:"is_announcement"`     //
+	NSFW              bool                       `json:"nsfw"`                // True if the thread is tagged as NSFW
+	ResourcesHTML     string                     `json:"resources_html"`      // Formatted HTML resources sidebar
+	Resources         string                     `json:"resources"`           // Raw markdown resources sidebar
+	State             string                     `json:"state"`               // "live" or "complete"
+	Title             string                     `json:"title"`               //
+	ViewerCountFuzzed bool                       `json:"viewer_count_fuzzed"` // True if the viewer count is approximate
+	ViewerCount       int                        `json:"viewer_count"`        //
+	WebsocketURL      string                     `json:"websocket_url"`       //
+	Created
+}
+
+// UnmarshalJSON decodes a LiveUpdateEvent Thing data structure, unknown fields are kept in Extra
+func (t *LiveThread) UnmarshalJSON(b []byte) error {
+	type thread LiveThread
+	extra, err := unmarshalExtra(b, (*thread)(t))
+	if err != nil {
+		return err
+	}
+	t.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the thread as sent by Reddit, including Extra fields
+func (t LiveThread) MarshalJSON() ([]byte, error) {
+	type thread LiveThread
+	return marshalExtra((*thread)(&t), t.Extra)
+}
+
+// Live returns true if the thread is still accepting updates
+func (t *LiveThread) Live() bool {
+	return t.State == liveStateLive
+}
+
+// LiveUpdate represents a single update posted to a live thread
+type LiveUpdate struct {
+	Author   string                     `json:"author"`    // Account name of the poster
+	BodyHTML string                     `json:"body_html"` // Formatted HTML text as displayed on Reddit
+	Body     string                     `json:"body"`      // Raw markdown text of the update
+	Embeds   json.RawMessage            `json:"embeds"`    //
+	Extra    map[string]json.RawMessage `json:"-"`         // Fields not decoded into the struct, kept when encoding
+	ID       string                     `json:"id"`        // Update identifier, a UUID
+	Name     string                     `json:"name"`      // Fullname of item, e.g. "LiveUpdate_<id>"
+	Stricken bool                       `json:"stricken"`  // True if the update has been struck through
+	Created
+}
+
+// UnmarshalJSON decodes a LiveUpdate Thing data structure, unknown fields are kept in Extra
+func (u *LiveUpdate) UnmarshalJSON(b []byte) error {
+	type update LiveUpdate
+	extra, err := unmarshalExtra(b, (*update)(u))
+	if err != nil {
+		return err
+	}
+	u.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the update as sent by Reddit, including Extra fields
+func (u LiveUpdate) MarshalJSON() ([]byte, error) {
+	type update LiveUpdate
+	return marshalExtra((*update)(&u), u.Extra)
+}
+
+// LiveContributor represents a contributor, or invited contributor, of a live thread
+type LiveContributor struct {
+	Extra       map[string]json.RawMessage `json:"-"`           // Fields not decoded into the struct, kept when encoding
+	ID          Fullname                   `json:"id"`          // Fullname of the user, e.g. "t2_c3v7f8u"
+	Invited     bool                       `json:"-"`           // True if the invitation is not yet accepted
+	Name        string                     `json:"name"`        // Account name of the user
+	Permissions []string                   `json:"permissions"` // Granted LivePerm* permissions
+}
+
+// UnmarshalJSON decodes a live thread contributor, unknown fields are kept in Extra
+func (c *LiveContributor) UnmarshalJSON(b []byte) error {
+	type contributor LiveContributor
+	extra, err := unmarshalExtra(b, (*contributor)(c))
+	if err != nil {
+		return err
+	}
+	c.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the contributor as sent by Reddit, including Extra fields
+func (c LiveContributor) MarshalJSON() ([]byte, error) {
+	type contributor LiveContributor
+	return marshalExtra((*contributor)(&c), c.Extra)
+}
+
+// LiveEvent is emitted by Session.StreamLive for each new update or failed poll
+type LiveEvent struct {
+	Err    error      // Error polling the thread
+	Update LiveUpdate // New update, unset if Err is set
+}
+
+// LiveThread returns information about live thread t
+func (s *Session) LiveThread(t string) (*LiveThread, error) {
+	thread := LiveThread{}
+	err := s.getThing(s.url(fmt.Sprintf(apiLiveAbout, t)), nil, TypeLiveThread, &thread)
+	if err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// LiveUpdates returns the paginated updates of live thread t wrapped in a Page
+// type. Updates are listed newest first.
+func (s *Session) LiveUpdates(t string) *Page {
+	p := Page{}
+	p.s = s
+	p.url = s.url(fmt.Sprintf(apiLiveUpdates, t))
+	return &p
+}
+
+// PostLiveUpdate posts a new update to live thread t using the raw markdown text b
+func (s *Session) PostLiveUpdate(t string, b string) error {
+	v := url.Values{}
+	v.Set("body", b)
+
+	_, err := s.api(s.url(fmt.Sprintf(apiLiveUpdate, t)), v)
+	return err
+}
+
+// StrikeLiveUpdate strikes through update u of live thread t, marking
+// it as incorrect. The update may be given by ID or fullname.
+func (s *Session) StrikeLiveUpdate(t string, u string) error {
+	return s.liveUpdateAction(apiLiveStrikeUpdate, t, u)
+}
+
+// DeleteLiveUpdate deletes update u of live thread t. The update may be
+// given by ID or fullname.
+func (s *Session) DeleteLiveUpdate(t string, u string) error {
+	return s.liveUpdateAction(apiLiveDeleteUpdate, t, u)
+}
+
+// LiveContributors returns the contributors of live thread t. Pending
+// invitations are included if visible to the logged-in user.
+func (s *Session) LiveContributors(t string) ([]LiveContributor, error) {
+	var raw json.RawMessage
+	err := s.call("GET", s.url(fmt.Sprintf(apiLiveContributors, t)), nil, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	// Managers get both the contributor and the invitation lists
+	var lists []Thing
+	if json.Unmarshal(raw, &lists) != nil {
+		lists = make([]Thing, 1)
+		err = json.Unmarshal(raw, &lists[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var contributors []LiveContributor
+	for i, l := range lists {
+		if l.Kind != TypeUserList {
+			return nil, ErrUnexpectedKind
+		}
+		list := struct {
+			Children []LiveContributor `json:"children"`
+		}{}
+		err = json.Unmarshal(l.Data, &list)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range list.Children {
+			c.Invited = i > 0
+			contributors = append(contributors, c)
+		}
+	}
+	return contributors, nil
+}
+
+// InviteLiveContributor invites user u to contribute to live thread t with
+// the given LivePerm* permissions. No permissions grants all permissions.
+func (s *Session) InviteLiveContributor(t string, u string, perms []string) error {
+	return s.liveContributor(apiLiveInvite, t, u, perms, liveTypeInvitation)
+}
+
+// SetLiveContributorPermissions replaces the permissions of contributor u of
+// live thread t. No permissions grants all permissions.
+func (s *Session) SetLiveContributorPermissions(t string, u string, perms []string) error {
+	return s.liveContributor(apiLivePermissions, t, u, perms, liveTypeContributor)
+}
+
+// AcceptLiveInvite accepts an invitation to contribute to live thread t
+func (s *Session) AcceptLiveInvite(t string) error {
+	_, err := s.api(s.url(fmt.Sprintf(apiLiveAcceptInvite, t)), nil)
+	return err
+}
+
+// LeaveLive abdicates contributorship of live thread t
+func (s *Session) LeaveLive(t string) error {
+	_, err := s.api(s.url(fmt.Sprintf(apiLiveLeave, t)), nil)
+	return err
+}
+
+// RemoveLiveContributor removes the contributor with fullname id from live thread t
+func (s *Session) RemoveLiveContributor(t string, id Fullname) error {
+	if err := id.Validate(); err != nil {
+		return err
+	}
+	v := url.Values{}
+	v.Set("id", string(id))
+
+	_, err := s.api(s.url(fmt.Sprintf(apiLiveRemove, t)), v)
+	return err
+}
+
+// RevokeLiveInvite revokes the pending invitation of the user with fullname
+// id to live thread t
+func (s *Session) RevokeLiveInvite(t string, id Fullname) error {
+	if err := id.Validate(); err != nil {
+		return err
+	}
+	v := url.Values{}
+	v.Set("id", string(id))
+
+	_, err := s.api(s.url(fmt.Sprintf(apiLiveRevokeInvite, t)), v)
+	return err
+}
+
+// StreamLive polls live thread t once every interval and emits updates
+// published after the stream was started, oldest first. Failed polls are
+// emitted as events carrying the error and polling continues. The returned
+// channel is closed when stop is closed.
+func (s *Session) StreamLive(t string, interval time.Duration, stop <-chan struct{}) <-chan LiveEvent {
+	events := make(chan LiveEvent)
+	go func() {
+		defer close(events)
+		page := s.LiveUpdates(t)
+		page.SetLimit(MaxLimit)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// Skip updates published before the stream was started
+		started := false
+		for {
+			list, err := page.Next()
+			switch {
+			case err != nil:
+				select {
+				case events <- LiveEvent{Err: err}:
+				case <-stop:
+					return
+				}
+			case !started:
+				started = true
+			default:
+				updates := list.LiveUpdates()
+				for i := len(updates) - 1; i >= 0; i-- {
+					select {
+					case events <- LiveEvent{Update: updates[i]}:
+					case <-stop:
+						return
+					}
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return events
+}
+
+func (s *Session) liveUpdateAction(method string, t string, u string) error {
+	if !strings.HasPrefix(u, livePrefixUpdate) {
+		u = livePrefixUpdate + u
+	}
+	v := url.Values{}
+	v.Set("id", u)
+
+	_, err := s.api(s.url(fmt.Sprintf(method, t)), v)
+	return err
+}
+
+func (s *Session) liveContributor(method string, t string, u string, perms []string, kind string) error {
+	v := url.Values{}
+	v.Set("name", u)
+	v.Set("permissions", livePermissions(perms))
+	v.Set("type", kind)
+
+	_, err := s.api(s.url(fmt.Sprintf(method, t)), v)
+	return err
+}
+
+// livePermissions formats perms as a Reddit permission list, e.g. "-all,+update"
+func livePermissions(perms []string) string {
+	if len(perms) == 0 {
+		return "+" + LivePermAll
+	}
+	list := []string{"-" + LivePermAll}
+	for _, p := range perms {
+		list = append(list, "+"+p)
+	}
+	return strings.Join(list, ",")
+}
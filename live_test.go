@@ -0,0 +1,83 @@
+package rego
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStreamLive(t *testing.T) {
+	var lock sync.Mutex
+	updates := []string{"u2", "u1"} // Newest first
+	polled := make(chan struct{})
+	once := sync.Once{}
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		var children []string
+		for _, id := range updates {
+			if "LiveUpdate_"+id == r.FormValue("before") {
+				break
+			}
+			children = append(children, fmt.Sprintf(`{"kind": "LiveUpdate", "data": {"id": %q, "name": "LiveUpdate_%s", "body": "%s"}}`, id, id, id))
+		}
+		fmt.Fprintf(w, `{"kind": "Listing", "data": {"children": [%s]}}`, strings.Join(children, ","))
+		once.Do(func() { close(polled) })
+	}))
+	defer ts.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	events := s.StreamLive("thread", time.Millisecond, stop)
+
+	// Add updates once the existing ones have been seen
+	<-polled
+	lock.Lock()
+	updates = append([]string{"u4", "u3"}, updates...)
+	lock.Unlock()
+
+	for _, want := range []string{"u3", "u4"} {
+		ev := <-events
+		if ev.Err != nil {
+			t.Fatal(ev.Err)
+		}
+		if ev.Update.Body != want {
+			t.Errorf("Got update %q, wanted %q", ev.Update.Body, want)
+		}
+	}
+}
+
+func TestLiveContributors(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"kind": "UserList", "data": {"children": [{"id": "t2_a", "name": "a", "permissions": ["all"]}]}},
+			{"kind": "UserList", "data": {"children": [{"id": "t2_b", "name": "b", "permissions": ["update"]}]}}]`)
+	}))
+	defer ts.Close()
+
+	contributors, err := s.LiveContributors("thread")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contributors) != 2 || contributors[0].Invited || !contributors[1].Invited || contributors[1].Permissions[0] != LivePermUpdate {
+		t.Errorf("Got %+v", contributors)
+	}
+}
+
+func Test_livePermissions(t *testing.T) {
+	var tests = []struct {
+		perms  []string
+		result string
+	}{
+		{nil, "+all"},
+		{[]string{LivePermUpdate, LivePermEdit}, "-all,+update,+edit"},
+	}
+
+	for _, test := range tests {
+		if p := livePermissions(test.perms); p != test.result {
+			t.Errorf("Got %q, wanted %q", p, test.result)
+		}
+	}
+}
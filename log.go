@@ -0,0 +1,104 @@
+package rego
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// attemptKey is the context key of the request attempt number
+type attemptKey struct{}
+
+// WithAttempt returns a copy of ctx marking requests using it as attempt n,
+// counting from 1. Middleware retrying requests use it to have retries logged.
+func WithAttempt(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, n)
+}
+
+// attempt returns the attempt number of req
+func attempt(req *http.Request) int {
+	if n, ok := req.Context().Value(attemptKey{}).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// SetLogger enables logging of requests to l, or disables it if l is nil.
+// Each request is logged as a single record at level, or at least at
+// slog.LevelWarn if it failed, with the attributes method, endpoint, status,
+// duration, ratelimit_remaining and attempt.
+//
+// Endpoints are logged as templates, e.g. "/user/%s/about.json", and neither
+// URLs, headers nor bodies are logged, so credentials are never included.
+func (s *Session) SetLogger(l *slog.Logger, level slog.Level) {
+	s.logger = l
+	s.logLevel = level
+}
+
+// logRequest logs the result of request req, sent in d
+func (s *Session) logRequest(req *http.Request, resp *http.Response, err error, d time.Duration) {
+	if s.logger == nil {
+		return
+	}
+	level := s.logLevel
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("endpoint", endpointTemplate(req.URL.Path)),
+	}
+	if err != nil {
+		// URL errors repeat the full URL
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		level = max(level, slog.LevelWarn)
+		attrs = append(attrs, slog.String("error", err.Error()))
+	} else {
+		if resp.StatusCode >= 400 {
+			level = max(level, slog.LevelWarn)
+		}
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if r, err := strconv.Atoi(resp.Header.Get("X-Ratelimit-Remaining")); err == nil {
+			attrs = append(attrs, slog.Int("ratelimit_remaining", r))
+		}
+	}
+	attrs = append(attrs, slog.Duration("duration", d), slog.Int("attempt", attempt(req)))
+	s.logger.LogAttrs(req.Context(), level, "reddit request", attrs...)
+}
+
+// endpointPatterns matches request paths to the endpoint templates of api.go
+var endpointPatterns []struct {
+	re       *regexp.Regexp
+	template string
+}
+
+func init() {
+	for _, t := range endpoints {
+		// Parameters may be paths, e.g. of listings and multireddits
+		parts := strings.Split(t, "%s")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		endpointPatterns = append(endpointPatterns, struct {
+			re       *regexp.Regexp
+			template string
+		}{regexp.MustCompile("^" + strings.Join(parts, ".+") + "$"), t})
+	}
+}
+
+// endpointTemplate returns the API endpoint template of request path p,
+// "other" if p is not an endpoint
+func endpointTemplate(p string) string {
+	for _, e := range endpointPatterns {
+		if e.re.MatchString(p) {
+			return e.template
+		}
+	}
+	return "other"
+}
@@ -0,0 +1,86 @@
+package rego
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func Test_endpointTemplate(t *testing.T) {
+	var tests = []struct {
+		path     string
+		template string
+	}{
+		{"/api/me.json", apiMe},
+		{"/user/bob/about.json", apiUserAbout},
+		{"/r/golang/about.json", apiSubredditAbout},
+		{"/r/golang/wiki/about.json", apiWikiPage},
+		{"/r/golang/wiki/config/sidebar.json", apiWikiPage},
+		{"/r/golang/wiki/pages.json", apiWikiPages},
+		{"/api/multi/user/bob/m/go", apiMulti},
+		{"/api/multi/user/bob/m/go/r/golang", apiMultiSubreddit},
+		{"/api/multi/copy", apiMultiCopy},
+		{"/live/abc/about.json", apiLiveAbout},
+		{"/live/abc.json", apiLiveUpdates},
+		{"/r/golang/new.json", apiListing},
+		{"/s/abc", "other"},
+	}
+
+	for _, test := range tests {
+		if template := endpointTemplate(test.path); template != test.template {
+			t.Errorf("Got %q for %s, wanted %q", template, test.path, test.template)
+		}
+	}
+}
+
+func Test_SetLogger(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining", "42")
+		switch r.URL.Path {
+		case apiLogin:
+			fmt.Fprint(w, `{"json": {"errors": [], "data": {"cookie": "secret", "modhash": "secret"}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	buf := bytes.Buffer{}
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	s.SetLogger(slog.New(h), slog.LevelDebug)
+
+	err := s.Login("bob", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := s.newRequest("GET", s.url("/user/bob/about.json?key=secret"), nil)
+	req = req.WithContext(WithAttempt(context.Background(), 2))
+	resp, err := s.do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	want := []string{
+		`level=DEBUG msg="reddit request" method=POST endpoint=/api/login status=200 ratelimit_remaining=42 attempt=1`,
+		`level=WARN msg="reddit request" method=GET endpoint=/user/%s/about.json status=404 ratelimit_remaining=42 attempt=2`,
+	}
+	if got := strings.TrimSpace(buf.String()); got != strings.Join(want, "\n") {
+		t.Errorf("Got log\n%s\nwanted\n%s", got, strings.Join(want, "\n"))
+	}
+	if strings.Contains(buf.String(), "secret") || strings.Contains(buf.String(), "password") {
+		t.Errorf("Credentials logged")
+	}
+}
@@ -0,0 +1,491 @@
+package rego
+
+import (
+	"encoding/json"
+	"html"
+	"strings"
+	"time"
+)
+
+// htmlEscaper escapes the characters Reddit HTML escapes in media values
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// ImageSource represents a single resolution of an image. Animated images
+// may also have GIF and MP4 renditions.
+type ImageSource struct {
+	Extra  map[string]json.RawMessage `json:"-"`             // Fields not decoded into the struct, kept when encoding
+	GIF    string                     `json:"gif,omitempty"` // URL of the GIF rendition of an animated image
+	Height int                        `json:"height"`        //
+	MP4    string                     `json:"mp4,omitempty"` // URL of the MP4 rendition of an animated image
+	URL    string                     `json:"url"`           // URL of the image
+	Width  int                        `json:"width"`         //
+
+	abbreviated bool // Decoded from the u, x and y form of media metadata
+}
+
+// imageSource is the wire form of ImageSource, either form
+type imageSource struct {
+	GIF    string `json:"gif,omitempty"`
+	Height int    `json:"height,omitempty"`
+	MP4    string `json:"mp4,omitempty"`
+	U      string `json:"u,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	X      int    `json:"x,omitempty"`
+	Y      int    `json:"y,omitempty"`
+}
+
+// UnmarshalJSON decodes a preview image resolution or the abbreviated
+// form used by media metadata. URLs are HTML unescaped.
+func (i *ImageSource) UnmarshalJSON(b []byte) error {
+	data := imageSource{}
+	extra, err := unmarshalExtra(b, &data)
+	if err != nil {
+		return err
+	}
+
+	*i = ImageSource{
+		Extra:  extra,
+		GIF:    html.UnescapeString(data.GIF),
+		Height: data.Height,
+		MP4:    html.UnescapeString(data.MP4),
+		URL:    html.UnescapeString(data.URL),
+		Width:  data.Width,
+	}
+	if len(data.U) > 0 || data.X > 0 || data.Y > 0 {
+		i.abbreviated = true
+		i.URL = html.UnescapeString(data.U)
+		i.Height = data.Y
+		i.Width = data.X
+	}
+	return nil
+}
+
+// MarshalJSON encodes the image in the form it was decoded from, URLs HTML
+// escaped as sent by Reddit
+func (i ImageSource) MarshalJSON() ([]byte, error) {
+	data := imageSource{
+		GIF: htmlEscaper.Replace(i.GIF),
+		MP4: htmlEscaper.Replace(i.MP4),
+	}
+	if i.abbreviated {
+		data.U = htmlEscaper.Replace(i.URL)
+		data.X = i.Width
+		data.Y = i.Height
+		return marshalExtra(data, i.Extra)
+	}
+	return marshalExtra(struct {
+		imageSource
+		Height int    `json:"height"`
+		URL    string `json:"url"`
+		Width  int    `json:"width"`
+	}{data, i.Height, htmlEscaper.Replace(i.URL), i.Width}, i.Extra)
+}
+
+// ImageVariant is an alternative rendition of a preview image, e.g. "gif" or "nsfw"
+type ImageVariant struct {
+	Extra       map[string]json.RawMessage `json:"-"`           // Fields not decoded into the struct, kept when encoding
+	Resolutions []ImageSource              `json:"resolutions"` // Scaled down versions, smallest first
+	Source      ImageSource                `json:"source"`      // Full size image
+}
+
+// UnmarshalJSON decodes an image variant, unknown fields are kept in Extra
+func (v *ImageVariant) UnmarshalJSON(b []byte) error {
+	type variant ImageVariant
+	extra, err := unmarshalExtra(b, (*variant)(v))
+	v.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes the variant as sent by Reddit, including Extra fields
+func (v ImageVariant) MarshalJSON() ([]byte, error) {
+	type variant ImageVariant
+	return marshalExtra((*variant)(&v), v.Extra)
+}
+
+// PreviewImage is a preview image of a link with its resolution variants
+type PreviewImage struct {
+	Extra       map[string]json.RawMessage `json:"-"`           // Fields not decoded into the struct, kept when encoding
+	ID          string                     `json:"id"`          //
+	Resolutions []ImageSource              `json:"resolutions"` // Scaled down versions, smallest first
+	Source      ImageSource                `json:"source"`      // Full size image
+	Variants    map[string]ImageVariant    `json:"variants"`    // Keyed by variant, e.g. "gif", "mp4", "nsfw"
+}
+
+// UnmarshalJSON decodes a preview image, unknown fields are kept in Extra
+func (p *PreviewImage) UnmarshalJSON(b []byte) error {
+	type image PreviewImage
+	extra, err := unmarshalExtra(b, (*image)(p))
+	p.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes the preview image as sent by Reddit, including Extra fields
+func (p PreviewImage) MarshalJSON() ([]byte, error) {
+	type image PreviewImage
+	return marshalExtra((*image)(&p), p.Extra)
+}
+
+// Preview represents the preview images of a link
+type Preview struct {
+	Enabled            bool                       `json:"enabled"`              //
+	Extra              map[string]json.RawMessage `json:"-"`                    // Fields not decoded into the struct, kept when encoding
+	Images             []PreviewImage             `json:"images"`               //
+	RedditVideoPreview *RedditVideo               `json:"reddit_video_preview"` // Video rendition of e.g. a GIF link
+}
+
+// UnmarshalJSON decodes a link preview, unknown fields are kept in Extra
+func (p *Preview) UnmarshalJSON(b []byte) error {
+	type preview Preview
+	extra, err := unmarshalExtra(b, (*preview)(p))
+	p.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes the preview as sent by Reddit, including Extra fields
+func (p Preview) MarshalJSON() ([]byte, error) {
+	type preview Preview
+	return marshalExtra((*preview)(&p), p.Extra)
+}
+
+// RedditVideo represents a video hosted by Reddit, i.e. on v.redd.it
+type RedditVideo struct {
+	BitrateKbps       int                        `json:"bitrate_kbps"`       //
+	DashURL           string                     `json:"dash_url"`           // MPEG-DASH playlist
+	Duration          int                        `json:"duration"`           // Length in seconds
+	Extra             map[string]json.RawMessage `json:"-"`                  // Fields not decoded into the struct, kept when encoding
+	FallbackURL       string                     `json:"fallback_url"`       // MP4 video without audio
+	Height            int                        `json:"height"`             //
+	HLSURL            string                     `json:"hls_url"`            // HLS playlist
+	IsGIF             bool                       `json:"is_gif"`             //
+	ScrubberMediaURL  string                     `json:"scrubber_media_url"` //
+	TranscodingStatus string                     `json:"transcoding_status"` // e.g. "completed"
+	Width             int                        `json:"width"`              //
+}
+
+// UnmarshalJSON decodes a Reddit video, URLs are HTML unescaped
+func (v *RedditVideo) UnmarshalJSON(b []byte) error {
+	type video RedditVideo
+	extra, err := unmarshalExtra(b, (*video)(v))
+	if err != nil {
+		return err
+	}
+
+	v.DashURL = html.UnescapeString(v.DashURL)
+	v.Extra = extra
+	v.FallbackURL = html.UnescapeString(v.FallbackURL)
+	v.HLSURL = html.UnescapeString(v.HLSURL)
+	v.ScrubberMediaURL = html.UnescapeString(v.ScrubberMediaURL)
+	return nil
+}
+
+// MarshalJSON encodes the video as sent by Reddit, URLs HTML escaped
+func (v RedditVideo) MarshalJSON() ([]byte, error) {
+	type video RedditVideo
+	v.DashURL = htmlEscaper.Replace(v.DashURL)
+	v.FallbackURL = htmlEscaper.Replace(v.FallbackURL)
+	v.HLSURL = htmlEscaper.Replace(v.HLSURL)
+	v.ScrubberMediaURL = htmlEscaper.Replace(v.ScrubberMediaURL)
+	return marshalExtra((*video)(&v), v.Extra)
+}
+
+// OEmbed represents embedded media of an external provider, e.g. YouTube
+type OEmbed struct {
+	AuthorName      string                     `json:"author_name"`      //
+	AuthorURL       string                     `json:"author_url"`       //
+	Extra           map[string]json.RawMessage `json:"-"`                // Fields not decoded into the struct, kept when encoding
+	Height          int                        `json:"height"`           //
+	HTML            string                     `json:"html"`             // Embed HTML, unescaped
+	ProviderName    string                     `json:"provider_name"`    // e.g. "YouTube"
+	ProviderURL     string                     `json:"provider_url"`     //
+	ThumbnailHeight int                        `json:"thumbnail_height"` //
+	ThumbnailURL    string                     `json:"thumbnail_url"`    //
+	ThumbnailWidth  int                        `json:"thumbnail_width"`  //
+	Title           string                     `json:"title"`            //
+	Type            string                     `json:"type"`             // e.g. "video" or "rich"
+	Width           int                        `json:"width"`            //
+}
+
+// UnmarshalJSON decodes an oEmbed structure, HTML and URLs are unescaped
+func (o *OEmbed) UnmarshalJSON(b []byte) error {
+	type oembed OEmbed
+	extra, err := unmarshalExtra(b, (*oembed)(o))
+	if err != nil {
+		return err
+	}
+
+	o.Extra = extra
+	o.HTML = html.UnescapeString(o.HTML)
+	o.ThumbnailURL = html.UnescapeString(o.ThumbnailURL)
+	return nil
+}
+
+// MarshalJSON encodes the oEmbed structure as sent by Reddit, HTML and URLs
+// escaped
+func (o OEmbed) MarshalJSON() ([]byte, error) {
+	type oembed OEmbed
+	o.HTML = htmlEscaper.Replace(o.HTML)
+	o.ThumbnailURL = htmlEscaper.Replace(o.ThumbnailURL)
+	return marshalExtra((*oembed)(&o), o.Extra)
+}
+
+// Media represents the media of a link, either a Reddit hosted video or
+// embedded media of an external provider
+type Media struct {
+	Extra       map[string]json.RawMessage `json:"-"`            // Fields not decoded into the struct, kept when encoding
+	OEmbed      *OEmbed                    `json:"oembed"`       // Embedded media, nil for Reddit videos
+	RedditVideo *RedditVideo               `json:"reddit_video"` // Reddit hosted video, nil for embedded media
+	Type        string                     `json:"type"`         // Provider domain, e.g. "youtube.com"
+}
+
+// UnmarshalJSON decodes link media, unknown fields are kept in Extra
+func (m *Media) UnmarshalJSON(b []byte) error {
+	type media Media
+	extra, err := unmarshalExtra(b, (*media)(m))
+	m.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes the media as sent by Reddit, including Extra fields
+func (m Media) MarshalJSON() ([]byte, error) {
+	type media Media
+	return marshalExtra((*media)(&m), m.Extra)
+}
+
+// MediaEmbed holds the HTML used to embed the media of a link
+type MediaEmbed struct {
+	Content        string                     `json:"content"`          // Embed HTML, unescaped
+	Extra          map[string]json.RawMessage `json:"-"`                // Fields not decoded into the struct, kept when encoding
+	Height         int                        `json:"height"`           //
+	MediaDomainURL string                     `json:"media_domain_url"` //
+	Scrolling      bool                       `json:"scrolling"`        //
+	Width          int                        `json:"width"`            //
+}
+
+// UnmarshalJSON decodes a media embed, the content HTML is unescaped
+func (m *MediaEmbed) UnmarshalJSON(b []byte) error {
+	type embed MediaEmbed
+	extra, err := unmarshalExtra(b, (*embed)(m))
+	if err != nil {
+		return err
+	}
+
+	m.Content = html.UnescapeString(m.Content)
+	m.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the media embed as sent by Reddit, the content HTML
+// escaped
+func (m MediaEmbed) MarshalJSON() ([]byte, error) {
+	type embed MediaEmbed
+	m.Content = htmlEscaper.Replace(m.Content)
+	return marshalExtra((*embed)(&m), m.Extra)
+}
+
+// GalleryItem is a single image of a gallery link
+type GalleryItem struct {
+	Caption     string                     `json:"caption"`      //
+	Extra       map[string]json.RawMessage `json:"-"`            // Fields not decoded into the struct, kept when encoding
+	ID          int                        `json:"id"`           //
+	MediaID     string                     `json:"media_id"`     // Key of the item in Link.MediaMetadata
+	OutboundURL string                     `json:"outbound_url"` //
+}
+
+// UnmarshalJSON decodes a gallery item, unknown fields are kept in Extra
+func (g *GalleryItem) UnmarshalJSON(b []byte) error {
+	type item GalleryItem
+	extra, err := unmarshalExtra(b, (*item)(g))
+	g.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes the gallery item as sent by Reddit, including Extra fields
+func (g GalleryItem) MarshalJSON() ([]byte, error) {
+	type item GalleryItem
+	return marshalExtra((*item)(&g), g.Extra)
+}
+
+// GalleryData lists the images of a gallery link in display order
+type GalleryData struct {
+	Extra map[string]json.RawMessage `json:"-"`     // Fields not decoded into the struct, kept when encoding
+	Items []GalleryItem              `json:"items"` //
+}
+
+// UnmarshalJSON decodes gallery data, unknown fields are kept in Extra
+func (g *GalleryData) UnmarshalJSON(b []byte) error {
+	type gallery GalleryData
+	extra, err := unmarshalExtra(b, (*gallery)(g))
+	g.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes the gallery data as sent by Reddit, including Extra fields
+func (g GalleryData) MarshalJSON() ([]byte, error) {
+	type gallery GalleryData
+	return marshalExtra((*gallery)(&g), g.Extra)
+}
+
+// MediaMetadata describes an image or video of a gallery link, or one
+// inlined in a self post
+type MediaMetadata struct {
+	DashURL     string                     `json:"dashUrl"` // MPEG-DASH playlist of a video
+	Extra       map[string]json.RawMessage `json:"-"`       // Fields not decoded into the struct, kept when encoding
+	HLSURL      string                     `json:"hlsUrl"`  // HLS playlist of a video
+	ID          string                     `json:"id"`      //
+	MIME        string                     `json:"m"`       // e.g. "image/jpg"
+	Resolutions []ImageSource              `json:"p"`       // Scaled down versions, smallest first
+	Source      ImageSource                `json:"s"`       // Full size image
+	Status      string                     `json:"status"`  // "valid" once processed
+	Type        string                     `json:"e"`       // e.g. "Image", "AnimatedImage" or "RedditVideo"
+}
+
+// UnmarshalJSON decodes a media metadata entry, URLs are HTML unescaped
+func (m *MediaMetadata) UnmarshalJSON(b []byte) error {
+	type metadata MediaMetadata
+	extra, err := unmarshalExtra(b, (*metadata)(m))
+	if err != nil {
+		return err
+	}
+
+	m.DashURL = html.UnescapeString(m.DashURL)
+	m.Extra = extra
+	m.HLSURL = html.UnescapeString(m.HLSURL)
+	return nil
+}
+
+// MarshalJSON encodes the media metadata entry as sent by Reddit, URLs HTML
+// escaped
+func (m MediaMetadata) MarshalJSON() ([]byte, error) {
+	type metadata MediaMetadata
+	m.DashURL = htmlEscaper.Replace(m.DashURL)
+	m.HLSURL = htmlEscaper.Replace(m.HLSURL)
+	return marshalExtra((*metadata)(&m), m.Extra)
+}
+
+// URL returns the URL of the full size media, preferring the MP4 rendition
+// of animated images
+func (m *MediaMetadata) URL() string {
+	switch {
+	case len(m.Source.MP4) > 0:
+		return m.Source.MP4
+	case len(m.Source.GIF) > 0:
+		return m.Source.GIF
+	case len(m.Source.URL) > 0:
+		return m.Source.URL
+	}
+	return m.HLSURL
+}
+
+// PollOption is a single option of a poll
+type PollOption struct {
+	Extra     map[string]json.RawMessage `json:"-"`          // Fields not decoded into the struct, kept when encoding
+	ID        string                     `json:"id"`         //
+	Text      string                     `json:"text"`       //
+	VoteCount *int                       `json:"vote_count"` // Number of votes, nil until voting has ended or the user voted
+}
+
+// UnmarshalJSON decodes a poll option, unknown fields are kept in Extra
+func (p *PollOption) UnmarshalJSON(b []byte) error {
+	type option PollOption
+	extra, err := unmarshalExtra(b, (*option)(p))
+	p.Extra = extra
+	return err
+}
+
+// MarshalJSON encodes the poll option as sent by Reddit, including Extra fields
+func (p PollOption) MarshalJSON() ([]byte, error) {
+	type option PollOption
+	return marshalExtra((*option)(&p), p.Extra)
+}
+
+// PollData represents the poll of a poll link
+type PollData struct {
+	Extra          map[string]json.RawMessage `json:"-"`                // Fields not decoded into the struct, kept when encoding
+	IsPrediction   bool                       `json:"is_prediction"`    //
+	Options        []PollOption               `json:"options"`          //
+	TotalVoteCount int                        `json:"total_vote_count"` //
+	UserSelection  string                     `json:"user_selection"`   // Option ID voted for by the logged-in user
+	VotingEnds     time.Time                  `json:"-"`                // Time voting ends
+}
+
+// UnmarshalJSON decodes a poll, converting the millisecond end timestamp
+func (p *PollData) UnmarshalJSON(b []byte) error {
+	type poll PollData
+	data := struct {
+		*poll
+		VotingEnds int64 `json:"voting_end_timestamp"`
+	}{poll: (*poll)(p)}
+	extra, err := unmarshalExtra(b, &data)
+	if err != nil {
+		return err
+	}
+
+	p.Extra = extra
+	p.VotingEnds = time.Time{}
+	if data.VotingEnds > 0 {
+		p.VotingEnds = time.Unix(0, data.VotingEnds*int64(time.Millisecond))
+	}
+	return nil
+}
+
+// MarshalJSON encodes the poll as sent by Reddit, including Extra fields
+func (p PollData) MarshalJSON() ([]byte, error) {
+	type poll PollData
+	data := struct {
+		*poll
+		VotingEnds *int64 `json:"voting_end_timestamp"`
+	}{poll: (*poll)(&p)}
+	if !p.VotingEnds.IsZero() {
+		ms := p.VotingEnds.UnixNano() / int64(time.Millisecond)
+		data.VotingEnds = &ms
+	}
+	return marshalExtra(data, p.Extra)
+}
+
+// MediaURLs returns the URLs of all media assets of the link: a linked
+// image, gallery images in display order, Reddit hosted video, preview
+// images and the assets of crossposted links. Duplicates are removed.
+func (l *Link) MediaURLs() []string {
+	var urls []string
+	seen := map[string]bool{}
+	add := func(u string) {
+		if len(u) > 0 && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	if l.PostHint == "image" {
+		add(l.URL)
+	}
+	if l.GalleryData != nil {
+		for _, item := range l.GalleryData.Items {
+			if m, ok := l.MediaMetadata[item.MediaID]; ok {
+				add(m.URL())
+			}
+		}
+	}
+	for _, media := range []*Media{l.SecureMedia, l.Media} {
+		if media != nil && media.RedditVideo != nil {
+			add(media.RedditVideo.FallbackURL)
+		}
+	}
+	if l.Preview != nil {
+		for _, img := range l.Preview.Images {
+			if v, ok := img.Variants["mp4"]; ok {
+				add(v.Source.URL)
+			}
+			add(img.Source.URL)
+		}
+		if l.Preview.RedditVideoPreview != nil {
+			add(l.Preview.RedditVideoPreview.FallbackURL)
+		}
+	}
+	for i := range l.CrosspostParents {
+		for _, u := range l.CrosspostParents[i].MediaURLs() {
+			add(u)
+		}
+	}
+	return urls
+}
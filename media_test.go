@@ -0,0 +1,119 @@
+package rego
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLinkMedia(t *testing.T) {
+	b, err := os.ReadFile("testdata/link_gallery.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	thing := Thing{}
+	err = json.Unmarshal(b, &thing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := Link{}
+	err = json.Unmarshal(thing.Data, &l)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !l.IsGallery || l.GalleryData == nil || len(l.GalleryData.Items) != 2 || l.Media != nil {
+		t.Fatalf("Got %#v, wanted a gallery", l)
+	}
+	img := l.MediaMetadata["img1"]
+	if img.Source.URL != "https://preview.redd.it/img1.jpg?width=1024&s=b" || img.Source.Width != 1024 {
+		t.Errorf("Got source %#v", img.Source)
+	}
+	if len(img.Resolutions) != 1 || img.Resolutions[0].Height != 108 {
+		t.Errorf("Got resolutions %#v", img.Resolutions)
+	}
+
+	cp := l.CrosspostParents
+	if len(cp) != 1 || cp[0].SecureMedia == nil || cp[0].SecureMedia.RedditVideo == nil {
+		t.Fatalf("Got crossposts %#v", cp)
+	}
+	if v := cp[0].SecureMedia.RedditVideo; v.HLSURL != "https://v.redd.it/def/HLSPlaylist.m3u8?a=1&v=1" || v.Duration != 12 {
+		t.Errorf("Got video %#v", v)
+	}
+
+	p := l.PollData
+	if p == nil || p.TotalVoteCount != 7 || len(p.Options) != 2 || p.Options[1].VoteCount != nil {
+		t.Fatalf("Got poll %#v", p)
+	}
+	if !p.VotingEnds.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("Got voting end %s", p.VotingEnds)
+	}
+
+	urls := l.MediaURLs()
+	expected := []string{
+		"https://preview.redd.it/img2.gif?format=mp4&s=c",
+		"https://preview.redd.it/img1.jpg?width=1024&s=b",
+		"https://v.redd.it/def/DASH_720.mp4?source=fallback",
+		"https://external-preview.redd.it/p1.jpg?auto=webp&s=d",
+	}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("Got %q, wanted %q", urls, expected)
+	}
+}
+
+func TestMediaEmbed(t *testing.T) {
+	l := Link{}
+	err := json.Unmarshal([]byte(`{"post_hint": "image", "url": "https://i.redd.it/x.png",
+		"media": {"type": "youtube.com", "oembed": {"provider_name": "YouTube", "html": "&lt;iframe&gt;&lt;/iframe&gt;"}},
+		"media_embed": {"content": "&lt;iframe src=\"a?b=1&amp;c=2\"&gt;", "width": 356}}`), &l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Media == nil || l.Media.OEmbed == nil || l.Media.OEmbed.HTML != "<iframe></iframe>" {
+		t.Errorf("Got media %#v", l.Media)
+	}
+	if l.MediaEmbed.Content != `<iframe src="a?b=1&c=2">` || l.MediaEmbed.Width != 356 {
+		t.Errorf("Got media embed %#v", l.MediaEmbed)
+	}
+	if urls := l.MediaURLs(); len(urls) != 1 || urls[0] != l.URL {
+		t.Errorf("Got %q, wanted [%q]", urls, l.URL)
+	}
+}
+
+func TestMediaEscaping(t *testing.T) {
+	l := Link{}
+	decodeTestdata(t, "link_media.json", &l)
+
+	if l.MediaEmbed.Content != `<iframe src="https://www.youtube.com/embed/xyz?a=1&amp;b=2" title="Tom &amp;amp; Jerry"></iframe>` {
+		t.Errorf("Got media embed content %q", l.MediaEmbed.Content)
+	}
+	if l.Media == nil || l.Media.OEmbed == nil || l.Media.OEmbed.ThumbnailURL != "https://i.ytimg.com/vi/xyz/hqdefault.jpg?a=1&amp;b=2" {
+		t.Fatalf("Got media %#v", l.Media)
+	}
+	if v := l.Media.OEmbed.Extra["version"]; string(v) != `"1.0"` {
+		t.Errorf("Got oEmbed extra %q", l.Media.OEmbed.Extra)
+	}
+	img := l.Preview.Images[0]
+	if img.Source.URL != "https://external-preview.redd.it/p2.jpg?auto=webp&amp;s=f" {
+		t.Errorf("Got source %q", img.Source.URL)
+	}
+	if v := img.Variants["nsfw"].Extra["obfuscated"]; string(v) != "true" {
+		t.Errorf("Got variant extra %q", img.Variants["nsfw"].Extra)
+	}
+
+	// Encoding escapes again, decoding the encoding gives the same values
+	b, err := json.Marshal(l.MediaEmbed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := MediaEmbed{}
+	err = json.Unmarshal(b, &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(e, l.MediaEmbed) {
+		t.Errorf("Got %#v, wanted %#v", e, l.MediaEmbed)
+	}
+}
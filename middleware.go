@@ -0,0 +1,98 @@
+package rego
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUserAgent = errors.New("missing or generic user agent")
+)
+
+// Doer is the interface of HTTP clients sending requests, e.g. *http.Client
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc is an adapter allowing the use of ordinary functions as Doer
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req)
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the Doer sending the requests of a session, e.g. to log,
+// modify or fail requests
+type Middleware func(next Doer) Doer
+
+// Use adds middleware m to the request pipeline of the session. Requests
+// sent flow through the pipeline, the first middleware added is called first.
+//
+// Fresh replies of the response cache, see SetCache, are served without
+// sending a request and bypass the pipeline: neither middleware, logging nor
+// instrumentation see them, and the rate limit is not updated. Requests
+// revalidating expired entries flow through the pipeline.
+func (s *Session) Use(m ...Middleware) {
+	s.middleware = append(s.middleware, m...)
+}
+
+// doer returns the HTTP client of the session wrapped by its middleware
+func (s *Session) doer() Doer {
+	var d Doer = DoerFunc(s.observe)
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		d = s.middleware[i](d)
+	}
+	return d
+}
+
+// LogRequests returns a Middleware logging the method, URL, status and
+// duration of each request to l
+func LogRequests(l *log.Logger) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.Do(req)
+			d := time.Since(start).Round(time.Millisecond)
+			if err != nil {
+				l.Printf("%s %s: %v (%v)", req.Method, req.URL, err, d)
+			} else {
+				l.Printf("%s %s: %s (%v)", req.Method, req.URL, resp.Status, d)
+			}
+			return resp, err
+		})
+	}
+}
+
+// UserAgent returns a Middleware enforcing the user agent of requests.
+// Requests without one are sent using ua. Requests with a missing or generic
+// user agent, e.g. the Go default, are failed with ErrUserAgent as Reddit
+// heavily rate limits them.
+func UserAgent(ua string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if len(req.Header.Get("User-Agent")) == 0 && len(ua) > 0 {
+				req = req.Clone(req.Context())
+				req.Header.Set("User-Agent", ua)
+			}
+			if genericUserAgent(req.Header.Get("User-Agent")) {
+				return nil, ErrUserAgent
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// genericUserAgent reports whether ua is empty or a library default
+func genericUserAgent(ua string) bool {
+	ua = strings.ToLower(strings.TrimSpace(ua))
+	for _, prefix := range []string{"go-http-client", "curl/", "python-requests", "python-urllib", "wget/"} {
+		if strings.HasPrefix(ua, prefix) {
+			return true
+		}
+	}
+	return len(ua) == 0
+}
@@ -0,0 +1,112 @@
+package rego
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func Test_Middleware(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case apiComment:
+			fmt.Fprint(w, `{"json": {"errors": [], "data": {"things": [{"kind": "t1", "data": {"id": "t1_c"}}]}}}`)
+		default:
+			fmt.Fprint(w, `{"kind": "t2", "data": {"name": "bob"}}`)
+		}
+	}))
+	defer ts.Close()
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Doer) Doer {
+			return DoerFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+" "+req.Method)
+				return next.Do(req)
+			})
+		}
+	}
+	header := func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("X-Test", "1")
+			return next.Do(req)
+		})
+	}
+	s.Use(trace("a"), trace("b"), header)
+
+	_, err := s.User("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Comment("t3_x", "Hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, ",") != "a GET,b GET,a POST,b POST" {
+		t.Errorf("Got %v, wanted GET and POST through the middleware in order", order)
+	}
+
+	// Fault injection
+	errFault := errors.New("fault")
+	s.Use(func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errFault
+		})
+	})
+	_, err = s.User("bob")
+	if !errors.Is(err, errFault) {
+		t.Errorf("Got %v, wanted the injected fault", err)
+	}
+}
+
+func Test_LogRequests(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	buf := bytes.Buffer{}
+	s.Use(LogRequests(log.New(&buf, "", 0)))
+	s.User("bob")
+	if !strings.HasPrefix(buf.String(), "GET https://www.reddit.com/user/bob/about.json: 404 Not Found (") {
+		t.Errorf("Got log %q", buf.String())
+	}
+}
+
+func Test_UserAgent(t *testing.T) {
+	var tests = []struct {
+		header string
+		ua     string
+		sent   string
+		err    error
+	}{
+		{"RegoTest/1.0", "", "RegoTest/1.0", nil},
+		{"", "linux:rego:1.0 (by /u/bob)", "linux:rego:1.0 (by /u/bob)", nil},
+		{"", "", "", ErrUserAgent},
+		{"Go-http-client/1.1", "", "", ErrUserAgent},
+		{"python-requests/2.31", "RegoTest/1.0", "", ErrUserAgent},
+	}
+
+	for i, test := range tests {
+		var sent string
+		d := UserAgent(test.ua)(DoerFunc(func(req *http.Request) (*http.Response, error) {
+			sent = req.Header.Get("User-Agent")
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}))
+		req, _ := http.NewRequest("GET", "https://www.reddit.com/", nil)
+		req.Header.Set("User-Agent", test.header)
+		_, err := d.Do(req)
+		if err != test.err || sent != test.sent {
+			t.Errorf("%d: Got %q, %v, wanted %q, %v", i, sent, err, test.sent, test.err)
+		}
+	}
+}
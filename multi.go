@@ -0,0 +1,217 @@
+package rego
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Multireddit visibility
+const (
+	MultiHidden  = "hidden"
+	MultiPrivate = "private"
+	MultiPublic  = "public"
+)
+
+// Multireddit represents a user curated collection of subreddits
+type Multireddit struct {
+	CanEdit         bool                       `json:"can_edit"`         // True if the logged-in user may edit the multi
+	DescriptionHTML string                     `json:"description_html"` // Formatted HTML description
+	Description     string                     `json:"description_md"`   // Raw markdown description
+	DisplayName     string                     `json:"display_name"`     // Display name, may differ from Name
+	Extra           map[string]json.RawMessage `json:"-"`                // Fields not decoded into the struct, kept when encoding
+	IconURL         string                     `json:"icon_url"`         //
+	KeyColor        string                     `json:"key_color"`        // Hex color, e.g. "#cee3f8"
+	Name            string                     `json:"name"`             // Name used in the multi path
+	Over18          bool                       `json:"over_18"`          // True if the multi contains NSFW subreddits
+	Owner           string                     `json:"owner"`            // Account name of the owner
+	Path            string                     `json:"path"`             // Relative URL, e.g. "/user/name/m/multi"
+	Subreddits      MultiSubreddits            `json:"subreddits"`       // Names of the subreddits in the multi
+	Visibility      string                     `json:"visibility"`       // One of MultiHidden, MultiPrivate or MultiPublic
+	WeightingScheme string                     `json:"weighting_scheme"` // "classic" or "fresh"
+	Created
+}
+
+// UnmarshalJSON decodes a LabeledMulti Thing data structure, unknown fields are kept in Extra
+func (m *Multireddit) UnmarshalJSON(b []byte) error {
+	type multi Multireddit
+	extra, err := unmarshalExtra(b, (*multi)(m))
+	if err != nil {
+		return err
+	}
+	m.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the multireddit as sent by Reddit, including Extra fields
+func (m Multireddit) MarshalJSON() ([]byte, error) {
+	type multi Multireddit
+	return marshalExtra((*multi)(&m), m.Extra)
+}
+
+// MultiSubreddits is the list of subreddit names of a Multireddit
+type MultiSubreddits []string
+
+// MarshalJSON encodes the subreddit names in Reddit's [{"name": ...}] format
+func (m MultiSubreddits) MarshalJSON() ([]byte, error) {
+	items := []map[string]string{}
+	for _, name := range m {
+		items = append(items, map[string]string{"name": name})
+	}
+	return json.Marshal(items)
+}
+
+// UnmarshalJSON decodes the subreddit names from Reddit's [{"name": ...}] format
+func (m *MultiSubreddits) UnmarshalJSON(b []byte) error {
+	items := []struct {
+		Name string `json:"name"`
+	}{}
+	err := json.Unmarshal(b, &items)
+	if err != nil {
+		return err
+	}
+
+	*m = nil
+	for _, item := range items {
+		*m = append(*m, item.Name)
+	}
+	return nil
+}
+
+// MyMultis returns the multireddits of the logged-in user
+func (s *Session) MyMultis() ([]Multireddit, error) {
+	things := []Thing{}
+	err := s.call("GET", s.url(apiMultiMine), nil, &things)
+	if err != nil {
+		return nil, err
+	}
+
+	var multis []Multireddit
+	for _, t := range things {
+		m, err := unmarshalMulti(t)
+		if err != nil {
+			return nil, err
+		}
+		multis = append(multis, *m)
+	}
+	return multis, nil
+}
+
+// Multi returns multireddit name of user u
+func (s *Session) Multi(u string, name string) (*Multireddit, error) {
+	m := Multireddit{}
+	err := s.getThing(s.url(fmt.Sprintf(apiMulti, multiPath(u, name))), nil, TypeMulti, &m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// CreateMulti creates multireddit name of user u. The description, display
+// name, key color, subreddits, visibility and weighting scheme of m are used.
+func (s *Session) CreateMulti(u string, name string, m Multireddit) (*Multireddit, error) {
+	return s.multi("POST", multiPath(u, name), m)
+}
+
+// UpdateMulti replaces multireddit name of user u, creating it if it does not exist.
+// The description, display name, key color, subreddits, visibility and
+// weighting scheme of m are used.
+func (s *Session) UpdateMulti(u string, name string, m Multireddit) (*Multireddit, error) {
+	return s.multi("PUT", multiPath(u, name), m)
+}
+
+// DeleteMulti deletes multireddit name of user u
+func (s *Session) DeleteMulti(u string, name string) error {
+	return s.call("DELETE", s.url(fmt.Sprintf(apiMulti, multiPath(u, name))), nil, nil)
+}
+
+// CopyMulti copies multireddit name of user u to multireddit toName of user toUser
+func (s *Session) CopyMulti(u string, name string, toUser string, toName string) (*Multireddit, error) {
+	v := url.Values{}
+	v.Set("from", multiPath(u, name))
+	v.Set("to", multiPath(toUser, toName))
+	v.Set("display_name", toName)
+
+	return s.multiThing("POST", s.url(apiMultiCopy), v)
+}
+
+// RenameMulti renames multireddit name of user u to newName
+func (s *Session) RenameMulti(u string, name string, newName string) (*Multireddit, error) {
+	v := url.Values{}
+	v.Set("from", multiPath(u, name))
+	v.Set("to", multiPath(u, newName))
+	v.Set("display_name", newName)
+
+	return s.multiThing("POST", s.url(apiMultiRename), v)
+}
+
+// AddMultiSubreddit adds subreddit sub to multireddit name of user u
+func (s *Session) AddMultiSubreddit(u string, name string, sub string) error {
+	model, _ := json.Marshal(map[string]string{"name": sub})
+	v := url.Values{}
+	v.Set("model", string(model))
+
+	return s.call("PUT", s.url(fmt.Sprintf(apiMultiSubreddit, multiPath(u, name), sub)), v, nil)
+}
+
+// RemoveMultiSubreddit removes subreddit sub from multireddit name of user u
+func (s *Session) RemoveMultiSubreddit(u string, name string, sub string) error {
+	return s.call("DELETE", s.url(fmt.Sprintf(apiMultiSubreddit, multiPath(u, name), sub)), nil, nil)
+}
+
+// MultiListing returns the paginated links of multireddit name of user u
+// wrapped in a Page type. The sort order is one of the Sort* constants.
+func (s *Session) MultiListing(u string, name string, sort string) *Page {
+	if len(sort) == 0 {
+		sort = SortHot
+	}
+	p := Page{}
+	p.s = s
+	p.url = s.url(fmt.Sprintf(apiMultiListing, u, name, sort))
+	return &p
+}
+
+func (s *Session) multi(method string, path string, m Multireddit) (*Multireddit, error) {
+	model := struct {
+		Description     string          `json:"description_md,omitempty"`
+		DisplayName     string          `json:"display_name,omitempty"`
+		KeyColor        string          `json:"key_color,omitempty"`
+		Subreddits      MultiSubreddits `json:"subreddits"`
+		Visibility      string          `json:"visibility,omitempty"`
+		WeightingScheme string          `json:"weighting_scheme,omitempty"`
+	}{m.Description, m.DisplayName, m.KeyColor, m.Subreddits, m.Visibility, m.WeightingScheme}
+	b, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+
+	v := url.Values{}
+	v.Set("model", string(b))
+	return s.multiThing(method, s.url(fmt.Sprintf(apiMulti, path)), v)
+}
+
+func (s *Session) multiThing(method string, u string, v url.Values) (*Multireddit, error) {
+	thing := Thing{}
+	err := s.call(method, u, v, &thing)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalMulti(thing)
+}
+
+func unmarshalMulti(t Thing) (*Multireddit, error) {
+	if t.Kind != TypeMulti {
+		return nil, ErrUnexpectedKind
+	}
+	m := Multireddit{}
+	err := json.Unmarshal(t.Data, &m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// multiPath returns the API path of multireddit name of user u
+func multiPath(u string, name string) string {
+	return fmt.Sprintf("user/%s/m/%s", u, name)
+}
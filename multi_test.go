@@ -0,0 +1,65 @@
+package rego
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCreateMulti(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/multi/user/bot/m/news" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		model := struct {
+			DisplayName string          `json:"display_name"`
+			Subreddits  MultiSubreddits `json:"subreddits"`
+			Visibility  string          `json:"visibility"`
+		}{}
+		err := json.Unmarshal([]byte(r.FormValue("model")), &model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(model.Subreddits) != 2 || model.Visibility != MultiPrivate {
+			t.Errorf("Got model %s", r.FormValue("model"))
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"kind": "LabeledMulti", "data": {"name": "news", "display_name": %q,
+			"path": "/user/bot/m/news", "visibility": "private", "created_utc": 1420070400.0,
+			"subreddits": [{"name": "worldnews"}, {"name": "news"}]}}`, model.DisplayName)
+	}))
+	defer ts.Close()
+
+	m, err := s.CreateMulti("bot", "news", Multireddit{
+		DisplayName: "News",
+		Subreddits:  MultiSubreddits{"worldnews", "news"},
+		Visibility:  MultiPrivate,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.DisplayName != "News" || m.Path != "/user/bot/m/news" || len(m.Subreddits) != 2 || m.Subreddits[0] != "worldnews" {
+		t.Errorf("Got %+v", m)
+	}
+}
+
+func TestMultiListing(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user/bot/m/news/top.json" || r.FormValue("t") != TimeWeek {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		fmt.Fprint(w, `{"kind": "Listing", "data": {"children": [{"kind": "t3", "data": {"name": "t3_a", "title": "A"}}]}}`)
+	}))
+	defer ts.Close()
+
+	page := s.MultiListing("bot", "news", SortTop)
+	page.SetTime(TimeWeek)
+	list, err := page.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links := list.Links(); len(links) != 1 || links[0].Title != "A" {
+		t.Errorf("Got %+v", links)
+	}
+}
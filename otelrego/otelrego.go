@@ -0,0 +1,74 @@
+// Package otelrego reports the requests of a rego.Session as OpenTelemetry
+// spans. It is a separate package so that only users importing it depend on
+// OpenTelemetry:
+//
+//	s := rego.NewSession("TracedBot/1.0")
+//	s.SetInstrumentation(otelrego.New(otel.GetTracerProvider()))
+//
+// Each request attempt is a client span, a child of the span of the request
+// context. Retries, marked using rego.WithAttempt, are linked to the span of
+// the previous attempt.
+package otelrego
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/c0rner/rego"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope name of the tracer
+const ScopeName = "github.com/c0rner/rego/otelrego"
+
+// Instrumentation is a rego.Instrumentation creating spans
+type Instrumentation struct {
+	tracer trace.Tracer
+}
+
+// New returns an Instrumentation creating spans using a tracer of tp
+func New(tp trace.TracerProvider) *Instrumentation {
+	return &Instrumentation{tracer: tp.Tracer(ScopeName)}
+}
+
+// StartRequest starts the span of a request attempt
+func (i *Instrumentation) StartRequest(ctx context.Context, r rego.RequestInfo) context.Context {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.template", r.Endpoint),
+			attribute.Int("http.request.resend_count", r.Attempt-1),
+		),
+	}
+	if r.Previous != nil {
+		if prev := trace.SpanContextFromContext(r.Previous); prev.IsValid() {
+			opts = append(opts, trace.WithLinks(trace.Link{
+				SpanContext: prev,
+				Attributes:  []attribute.KeyValue{attribute.String("rego.link", "retry")},
+			}))
+		}
+	}
+	ctx, _ = i.tracer.Start(ctx, r.Method+" "+r.Endpoint, opts...)
+	return ctx
+}
+
+// EndRequest ends the span of a request attempt
+func (i *Instrumentation) EndRequest(ctx context.Context, r rego.RequestInfo, res rego.RequestResult) {
+	span := trace.SpanFromContext(ctx)
+	if res.Err != nil {
+		span.RecordError(res.Err)
+		span.SetStatus(codes.Error, res.Err.Error())
+	} else {
+		span.SetAttributes(attribute.Int("http.response.status_code", res.Status))
+		if res.Status >= 400 {
+			span.SetStatus(codes.Error, http.StatusText(res.Status))
+		}
+	}
+	if res.Remaining >= 0 {
+		span.SetAttributes(attribute.Int("rego.ratelimit.remaining", res.Remaining))
+	}
+	span.End()
+}
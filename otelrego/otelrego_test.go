@@ -0,0 +1,101 @@
+package otelrego
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/c0rner/rego"
+	"github.com/c0rner/rego/redditest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpans(t *testing.T) {
+	srv := redditest.NewServer()
+	defer srv.Close()
+	srv.AddSubreddit("golang")
+	srv.SetRateLimit(1, 599, 0)
+	srv.Inject("/r/golang/about.json", redditest.Fault{Status: http.StatusServiceUnavailable})
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	s := rego.NewSession("TracedBot/1.0")
+	s.SetClient(srv.Client())
+	s.SetInstrumentation(New(tp))
+
+	// Retry the failed request in a middleware
+	s.Use(func(next rego.Doer) rego.Doer {
+		return rego.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, parent := tp.Tracer("test").Start(req.Context(), "call")
+			defer parent.End()
+			var resp *http.Response
+			var err error
+			for n := 1; n <= 2; n++ {
+				resp, err = next.Do(req.WithContext(rego.WithAttempt(ctx, n)))
+				if err == nil && resp.StatusCode < 500 {
+					break
+				}
+			}
+			return resp, err
+		})
+	})
+
+	_, err := s.Subreddit("golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Got %d spans, wanted two attempts and their parent", len(spans))
+	}
+	parent := spans[2]
+	for i, span := range spans[:2] {
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		if span.Name() != "GET /r/%s/about.json" || attrs["url.template"].AsString() != "/r/%s/about.json" {
+			t.Errorf("%d: Got span %q", i, span.Name())
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%d: Span is not a child of the parent span", i)
+		}
+		if n := attrs["http.request.resend_count"].AsInt64(); n != int64(i) {
+			t.Errorf("%d: Got resend count %d", i, n)
+		}
+		if n := attrs["rego.ratelimit.remaining"].AsInt64(); n != 599 {
+			t.Errorf("%d: Got remaining %d, wanted 599", i, n)
+		}
+	}
+
+	if spans[0].Status().Code != codes.Error || len(spans[0].Links()) != 0 {
+		t.Errorf("Got %v, %d links, wanted the failed first attempt unlinked", spans[0].Status(), len(spans[0].Links()))
+	}
+	links := spans[1].Links()
+	if len(links) != 1 || links[0].SpanContext.SpanID() != spans[0].SpanContext().SpanID() {
+		t.Errorf("Got links %v, wanted the retry linked to the first attempt", links)
+	}
+	if code := spans[1].Status().Code; code != codes.Unset {
+		t.Errorf("Got status %v, wanted the retry successful", code)
+	}
+}
+
+func TestError(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	i := New(tp)
+
+	info := rego.RequestInfo{Attempt: 1, Endpoint: "/api/me.json", Method: "GET"}
+	ctx := i.StartRequest(context.Background(), info)
+	i.EndRequest(ctx, info, rego.RequestResult{Err: fmt.Errorf("connection refused"), Remaining: -1})
+
+	spans := rec.Ended()
+	if len(spans) != 1 || spans[0].Status().Code != codes.Error || len(spans[0].Events()) != 1 {
+		t.Errorf("Got %v, wanted an error span with the recorded error", spans)
+	}
+}
@@ -3,7 +3,6 @@ package rego
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/url"
 )
 
@@ -12,6 +11,25 @@ const (
 	MaxLimit = 100
 )
 
+// Listing sort orders
+const (
+	SortControversial = "controversial"
+	SortHot           = "hot"
+	SortNew           = "new"
+	SortRising        = "rising"
+	SortTop           = "top"
+)
+
+// Listing time periods, used with the top and controversial sort orders
+const (
+	TimeAll   = "all"
+	TimeDay   = "day"
+	TimeHour  = "hour"
+	TimeMonth = "month"
+	TimeWeek  = "week"
+	TimeYear  = "year"
+)
+
 // Paginator is the interface that wraps methods for pagination of the Listing type
 type Paginator interface {
 	Next() (Lister, error)
@@ -25,6 +43,7 @@ type Page struct {
 	after  string // Fullname of reference Thing
 	before string // Fullname of reference Thing
 	limit  int    // Limit of items returned
+	period string // Time period of top and controversial listings
 }
 
 // Next returns a set of Thing items resulting from the requested API call.
@@ -68,41 +87,73 @@ func (p *Page) SetLimit(limit int) {
 	p.limit = limit
 }
 
-func (p *Page) list(v url.Values) (*Listing, error) {
-	resp, err := p.s.get(p.url, v)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// SetTime sets the time period of top and controversial listings, e.g. TimeWeek
+func (p *Page) SetTime(period string) {
+	p.period = period
+}
 
+func (p *Page) list(v url.Values) (*Listing, error) {
 	list := Listing{}
-	err = json.NewDecoder(resp.Body).Decode(&list)
+	err := p.s.call("GET", p.url, v, &list)
 	if err != nil {
 		return nil, err
 	}
 
 	if list.Kind != TypeListing {
-		// TODO.. handle error
-		log.Panic("Bricks!")
+		return nil, ErrUnexpectedKind
 	}
 
 	if len(list.Data.Children) > 0 {
-		item := struct {
-			Name string
-		}{}
-		err = json.Unmarshal(list.Data.Children[0].Data, &item)
-		p.before = item.Name
-		err = json.Unmarshal(list.Data.Children[len(list.Data.Children)-1].Data, &item)
-		p.after = item.Name
+		p.before = thingName(list.Data.Children[0])
+		p.after = thingName(list.Data.Children[len(list.Data.Children)-1])
+	}
+
+	// Listings of items lacking a fullname carry references of their own
+	if len(p.before) == 0 {
+		p.before = list.Data.Before
+	}
+	if len(p.after) == 0 {
+		p.after = list.Data.After
 	}
 
 	return &list, nil
 }
 
+// thingName returns the fullname of Thing t, empty if it has none
+func thingName(t Thing) string {
+	item := struct {
+		Name string
+	}{}
+	json.Unmarshal(t.Data, &item)
+	return item.Name
+}
+
+// thingID returns the fullname of Thing t, or its ID if it has no fullname
+func thingID(t Thing) string {
+	if len(t.Name) > 0 {
+		return t.Name
+	}
+	item := struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}{}
+	json.Unmarshal(t.Data, &item)
+	if len(item.Name) > 0 {
+		return item.Name
+	}
+	if len(item.ID) > 0 {
+		return item.ID
+	}
+	return t.ID
+}
+
 func (p *Page) values() url.Values {
 	v := url.Values{}
 	if p.limit > 0 {
 		v.Set("limit", fmt.Sprintf("%d", p.limit))
 	}
+	if len(p.period) > 0 {
+		v.Set("t", p.period)
+	}
 	return v
 }
@@ -0,0 +1,345 @@
+package rego
+
+import (
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoSession = errors.New("no authenticated session in pool")
+)
+
+// Strategy selects the account of a Pool used for a request
+type Strategy int
+
+// Pool strategies
+const (
+	RoundRobin Strategy = iota // Use the accounts in turn
+	LeastUsed                  // Use the account with the fewest requests in flight, then sent
+	Pinned                     // Use the same account for a key, e.g. a subreddit
+)
+
+// DefaultPoolRate is the default max number of requests per minute and account
+const DefaultPoolRate = 60
+
+// reauthDelay is the min time between re-authentication attempts of an account
+const reauthDelay = 30 * time.Second
+
+// AccountStats are the usage statistics of an account of a Pool
+type AccountStats struct {
+	Failed    bool      // True if the account failed to authenticate
+	InFlight  int       // Number of requests in flight
+	Name      string    // Account name
+	RateLimit RateLimit // Last rate limit reported for the account
+	Requests  int       // Number of requests sent
+}
+
+// Pool is a set of sessions, each authenticated as a different account,
+// spreading requests across the accounts. Each account is limited to a rate
+// of requests per minute and waits for its Reddit rate limit to reset when
+// exhausted. Accounts whose session expires are re-authenticated.
+//
+// A Pool is safe for concurrent use.
+type Pool struct {
+	lock       sync.Mutex
+	members    []*member
+	newSession func() *Session
+	next       int // Next member of RoundRobin
+	rate       int
+	strategy   Strategy
+}
+
+// member is an account of a Pool
+type member struct {
+	failed   time.Time // Time of the authentication failure, zero if authenticated
+	inflight int
+	limiter  limiter
+	name     string
+	password string
+	requests int
+	session  *Session
+}
+
+// NewPool returns an empty Pool using strategy. Accounts are logged in using
+// sessions returned by f, e.g.
+//
+//	pool := rego.NewPool(rego.RoundRobin, func() *rego.Session {
+//		return rego.NewSession("PoolBot/1.0")
+//	})
+func NewPool(strategy Strategy, f func() *Session) *Pool {
+	return &Pool{
+		newSession: f,
+		rate:       DefaultPoolRate,
+		strategy:   strategy,
+	}
+}
+
+// SetRate sets the max number of requests per minute and account of
+// accounts added afterwards. Zero disables the limit.
+func (p *Pool) SetRate(perMinute int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.rate = perMinute
+}
+
+// Add logs in account u using password pw and adds it to the pool
+func (p *Pool) Add(u string, pw string) error {
+	p.lock.Lock()
+	m := &member{name: u, password: pw}
+	if p.rate > 0 {
+		m.limiter.interval = time.Minute / time.Duration(p.rate)
+	}
+	p.lock.Unlock()
+
+	m.session = p.newSession()
+	m.session.Use(p.track(m))
+	err := m.session.Login(u, pw)
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.members = append(p.members, m)
+	return nil
+}
+
+// Session returns the session of the account selected by the strategy of
+// the pool. Key selects the account of Pinned pools and is ignored by other
+// strategies. Failed accounts are skipped, and re-authenticated using a new
+// session when chosen after a delay.
+func (p *Pool) Session(key string) (*Session, error) {
+	for {
+		s, m := p.pick(key)
+		if s != nil {
+			return s, nil
+		}
+		if m == nil {
+			return nil, ErrNoSession
+		}
+		s, err := p.reauthenticate(m)
+		if err == nil {
+			return s, nil
+		}
+	}
+}
+
+// pick returns the session of the account selected for key, or else the
+// failed member to re-authenticate, marked as failed now so that other
+// callers skip it meanwhile
+func (p *Pool) pick(key string) (*Session, *member) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	n := len(p.members)
+	if n == 0 {
+		return nil, nil
+	}
+
+	var order []int
+	switch p.strategy {
+	case LeastUsed:
+		best := -1
+		for i, m := range p.members {
+			if !m.failed.IsZero() {
+				continue
+			}
+			if best < 0 || m.inflight < p.members[best].inflight ||
+				(m.inflight == p.members[best].inflight && m.requests < p.members[best].requests) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			order = append(order, best)
+		}
+		for i := range p.members {
+			order = append(order, i)
+		}
+	case Pinned:
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		start := int(h.Sum32() % uint32(n))
+		for i := 0; i < n; i++ {
+			order = append(order, (start+i)%n)
+		}
+	default:
+		for i := 0; i < n; i++ {
+			order = append(order, (p.next+i)%n)
+		}
+		p.next = (p.next + 1) % n
+	}
+
+	var failed *member
+	for _, i := range order {
+		m := p.members[i]
+		if m.failed.IsZero() {
+			return m.session, nil
+		}
+		if failed == nil && time.Since(m.failed) >= reauthDelay {
+			failed = m
+		}
+	}
+	if failed != nil {
+		failed.failed = time.Now()
+	}
+	return nil, failed
+}
+
+// Reauthenticate logs in all failed accounts again using new sessions,
+// returning the first error
+func (p *Pool) Reauthenticate() error {
+	p.lock.Lock()
+	var failed []*member
+	for _, m := range p.members {
+		if !m.failed.IsZero() {
+			m.failed = time.Now()
+			failed = append(failed, m)
+		}
+	}
+	p.lock.Unlock()
+
+	var first error
+	for _, m := range failed {
+		_, err := p.reauthenticate(m)
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// reauthenticate logs in failed member m using a new session, replacing its
+// session if successful. The session in use by other goroutines is left
+// untouched.
+func (p *Pool) reauthenticate(m *member) (*Session, error) {
+	s := p.newSession()
+	s.Use(p.track(m))
+	err := s.Login(m.name, m.password)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if err != nil {
+		m.failed = time.Now()
+		return nil, err
+	}
+	m.failed = time.Time{}
+	m.session = s
+	return s, nil
+}
+
+// RateLimit returns the rate limit usage of the authenticated accounts
+// combined. Remaining and Used are summed, Reset is the time until the
+// first account rate limit resets.
+func (p *Pool) RateLimit() RateLimit {
+	total := RateLimit{}
+	first := true
+	for _, st := range p.Stats() {
+		if st.Failed {
+			continue
+		}
+		total.Remaining += st.RateLimit.Remaining
+		total.Used += st.RateLimit.Used
+		if first || st.RateLimit.Reset < total.Reset {
+			total.Reset = st.RateLimit.Reset
+		}
+		first = false
+	}
+	return total
+}
+
+// Stats returns the usage statistics of each account in the order added
+func (p *Pool) Stats() []AccountStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	stats := make([]AccountStats, 0, len(p.members))
+	for _, m := range p.members {
+		stats = append(stats, AccountStats{
+			Failed:    !m.failed.IsZero(),
+			InFlight:  m.inflight,
+			Name:      m.name,
+			RateLimit: m.session.RateLimit(),
+			Requests:  m.requests,
+		})
+	}
+	return stats
+}
+
+// track returns the Middleware of member m, rate limiting its requests,
+// counting them and marking m failed when its session is rejected
+func (p *Pool) track(m *member) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			wait := m.limiter.reserve(time.Now())
+			if wait > 0 {
+				t := time.NewTimer(wait)
+				select {
+				case <-t.C:
+				case <-req.Context().Done():
+					t.Stop()
+					return nil, req.Context().Err()
+				}
+			}
+
+			p.lock.Lock()
+			m.inflight++
+			m.requests++
+			p.lock.Unlock()
+
+			resp, err := next.Do(req)
+
+			p.lock.Lock()
+			m.inflight--
+			if err == nil && resp.StatusCode == http.StatusUnauthorized && req.URL.Path != apiLogin {
+				m.failed = time.Now()
+			}
+			p.lock.Unlock()
+
+			if err == nil {
+				m.limiter.update(resp.Header, time.Now())
+			}
+			return resp, err
+		})
+	}
+}
+
+// limiter spaces the requests of an account and holds them while its
+// Reddit rate limit is exhausted
+type limiter struct {
+	interval time.Duration // Min time between requests
+	lock     sync.Mutex
+	next     time.Time // Earliest time of the next request
+}
+
+// reserve reserves the next request slot and returns the time to wait for it
+func (l *limiter) reserve(now time.Time) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	return at.Sub(now)
+}
+
+// update holds requests until the reset of the rate limit reported by
+// reply headers h, if exhausted
+func (l *limiter) update(h http.Header, now time.Time) {
+	remaining, err := strconv.ParseFloat(h.Get("X-Ratelimit-Remaining"), 64)
+	if err != nil || remaining >= 1 {
+		return
+	}
+	reset, err := strconv.Atoi(h.Get("X-Ratelimit-Reset"))
+	if err != nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if at := now.Add(time.Duration(reset) * time.Second); at.After(l.next) {
+		l.next = at
+	}
+}
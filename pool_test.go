@@ -0,0 +1,194 @@
+package rego
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestPool returns a pool of accounts a, b and c served by a fake Reddit,
+// returning the account name of each request as rate limit used
+func newTestPool(t *testing.T, strategy Strategy, unauthorized *bool) *Pool {
+	var lock sync.Mutex
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		r.ParseForm()
+		switch r.URL.Path {
+		case apiLogin:
+			fmt.Fprintf(w, `{"json": {"errors": [], "data": {"cookie": "%s", "modhash": "m"}}}`, r.Form.Get("user"))
+			return
+		}
+		if *unauthorized {
+			*unauthorized = false
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		c, _ := r.Cookie(strCookie)
+		w.Header().Set("X-Ratelimit-Used", fmt.Sprint(c.Value[0]-'a'+1))
+		w.Header().Set("X-Ratelimit-Remaining", "10")
+		w.Header().Set("X-Ratelimit-Reset", fmt.Sprint(c.Value[0]-'a'+30))
+		fmt.Fprintf(w, `{"kind": "t2", "data": {"name": "%s"}}`, c.Value)
+	})
+
+	p := NewPool(strategy, func() *Session {
+		s, ts := newTestSession(h)
+		t.Cleanup(ts.Close)
+		return s
+	})
+	p.SetRate(0)
+	for _, u := range []string{"a", "b", "c"} {
+		err := p.Add(u, "pw")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return p
+}
+
+// use returns the account name of the session chosen for key
+func use(t *testing.T, p *Pool, key string) string {
+	t.Helper()
+	s, err := p.Session(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acct, err := s.Me()
+	if err != nil {
+		return "error"
+	}
+	return acct.Name
+}
+
+func Test_PoolStrategies(t *testing.T) {
+	unauthorized := false
+	p := newTestPool(t, RoundRobin, &unauthorized)
+	var names []string
+	for i := 0; i < 4; i++ {
+		names = append(names, use(t, p, ""))
+	}
+	if strings.Join(names, "") != "abca" {
+		t.Errorf("Got %v, wanted the accounts in turn", names)
+	}
+
+	p = newTestPool(t, Pinned, &unauthorized)
+	for _, key := range []string{"golang", "rust", "python"} {
+		first := use(t, p, key)
+		for i := 0; i < 3; i++ {
+			if name := use(t, p, key); name != first {
+				t.Errorf("Got %s for %s, wanted %s", name, key, first)
+			}
+		}
+	}
+
+	p = newTestPool(t, LeastUsed, &unauthorized)
+	use(t, p, "")
+	use(t, p, "")
+	if name := use(t, p, ""); name != "c" {
+		t.Errorf("Got %s, wanted the least used account", name)
+	}
+}
+
+func Test_PoolReauthenticate(t *testing.T) {
+	unauthorized := false
+	p := newTestPool(t, RoundRobin, &unauthorized)
+
+	unauthorized = true
+	if name := use(t, p, ""); name != "error" {
+		t.Errorf("Got %s, wanted the request rejected", name)
+	}
+	if st := p.Stats(); !st[0].Failed || st[1].Failed {
+		t.Errorf("Got %+v, wanted account a failed", st)
+	}
+
+	// Failed accounts are skipped
+	var names []string
+	for i := 0; i < 3; i++ {
+		names = append(names, use(t, p, ""))
+	}
+	if strings.Join(names, "") != "bcb" {
+		t.Errorf("Got %v, wanted account a skipped", names)
+	}
+
+	failed := p.members[0].session
+	err := p.Reauthenticate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st := p.Stats(); st[0].Failed {
+		t.Errorf("Got %+v, wanted account a authenticated", st[0])
+	}
+	if p.members[0].session == failed {
+		t.Errorf("Got the failed session, wanted a new session")
+	}
+
+	// Accounts chosen after the delay are re-authenticated
+	old := map[*Session]bool{}
+	for _, m := range p.members {
+		old[m.session] = true
+		m.failed = time.Now().Add(-reauthDelay)
+	}
+	s, err := p.Session("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticated := 0
+	for _, st := range p.Stats() {
+		if !st.Failed {
+			authenticated++
+		}
+	}
+	if old[s] || authenticated != 1 {
+		t.Errorf("Got %d accounts authenticated, wanted a new session of one", authenticated)
+	}
+}
+
+func Test_PoolRateLimit(t *testing.T) {
+	unauthorized := false
+	p := newTestPool(t, RoundRobin, &unauthorized)
+	for i := 0; i < 3; i++ {
+		use(t, p, "")
+	}
+
+	rl := p.RateLimit()
+	if rl.Remaining != 30 || rl.Used != 6 || rl.Reset != 30 {
+		t.Errorf("Got %+v, wanted the rate limits combined", rl)
+	}
+	for i, st := range p.Stats() {
+		// Login and me requests
+		if st.Requests != 2 || st.RateLimit.Used != i+1 {
+			t.Errorf("Got %+v for account %d", st, i)
+		}
+	}
+
+	p = NewPool(RoundRobin, nil)
+	_, err := p.Session("")
+	if err != ErrNoSession {
+		t.Errorf("Got %v, wanted ErrNoSession", err)
+	}
+}
+
+func Test_limiter(t *testing.T) {
+	now := time.Now()
+	l := limiter{interval: time.Second}
+	var waits []time.Duration
+	for i := 0; i < 3; i++ {
+		waits = append(waits, l.reserve(now))
+	}
+	if fmt.Sprint(waits) != "[0s 1s 2s]" {
+		t.Errorf("Got %v, wanted requests spaced", waits)
+	}
+
+	l = limiter{}
+	l.update(http.Header{"X-Ratelimit-Remaining": {"5.0"}, "X-Ratelimit-Reset": {"60"}}, now)
+	if w := l.reserve(now); w != 0 {
+		t.Errorf("Got %v, wanted no wait", w)
+	}
+	l.update(http.Header{"X-Ratelimit-Remaining": {"0.0"}, "X-Ratelimit-Reset": {"60"}}, now)
+	if w := l.reserve(now); w != time.Minute {
+		t.Errorf("Got %v, wanted the rate limit reset awaited", w)
+	}
+}
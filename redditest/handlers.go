@@ -0,0 +1,472 @@
+package redditest
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/c0rner/rego"
+)
+
+const (
+	cookieName   = "reddit_session"
+	defaultLimit = 25
+	deleted      = "[deleted]"
+)
+
+// item is a Thing listed in a reply
+type item struct {
+	kind string
+	name string
+	data interface{}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for k, v := range s.rateLimit {
+		w.Header()[k] = v
+	}
+	if f, ok := s.fault(r.URL.Path); ok {
+		writeFault(w, f)
+		return
+	}
+	r.ParseForm()
+
+	switch r.URL.Path {
+	case "/api/login":
+		s.serveLogin(w, r)
+		return
+	case "/api/v1/access_token":
+		s.serveAccessToken(w, r)
+		return
+	case "/api/me.json":
+		s.serveMe(w, r)
+		return
+	case "/api/info.json":
+		s.serveInfo(w, r)
+		return
+	case "/api/comment":
+		s.serveComment(w, r)
+		return
+	case "/api/submit":
+		s.serveSubmit(w, r)
+		return
+	case "/api/del":
+		s.serveDelete(w, r)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(r.URL.Path, ".json"), "/"), "/")
+	switch {
+	case len(parts) == 3 && parts[0] == "api" && parts[1] == "login":
+		// Legacy /api/login/{user}
+		s.serveLogin(w, r)
+	case len(parts) == 3 && parts[0] == "user" && parts[2] == "about":
+		s.serveUser(w, parts[1])
+	case len(parts) == 3 && parts[0] == "r" && parts[2] == "about":
+		s.serveSubreddit(w, parts[1])
+	case len(parts) == 3 && parts[0] == "r" && parts[2] == "comments":
+		s.serveSubredditComments(w, r, parts[1])
+	case len(parts) >= 2 && len(parts) <= 3 && parts[0] == "r":
+		order := ""
+		if len(parts) == 3 {
+			order = parts[2]
+		}
+		s.serveLinks(w, r, parts[1], order)
+	case len(parts) == 3 && parts[0] == "user" && parts[2] == "submitted":
+		s.serveUserLinks(w, r, parts[1])
+	case len(parts) == 3 && parts[0] == "user" && parts[2] == "comments":
+		s.serveUserComments(w, r, parts[1])
+	default:
+		writeStatus(w, http.StatusNotFound)
+	}
+}
+
+// authenticated returns the user of the session cookie or OAuth token
+// sent with r, nil if none
+func (s *Server) authenticated(r *http.Request) *user {
+	var token string
+	if c, err := r.Cookie(cookieName); err == nil {
+		token = c.Value
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+		token = auth[len("bearer "):]
+	}
+	if name, ok := s.sessions[token]; ok {
+		return s.users[name]
+	}
+	return nil
+}
+
+func (s *Server) serveLogin(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.users[r.Form.Get("user")]
+	if !ok || u.password != r.Form.Get("passwd") {
+		writeErrors(w, []string{string(rego.ErrWrongPassword), "wrong password", "passwd"})
+		return
+	}
+
+	writeAPI(w, map[string]interface{}{
+		"cookie":     s.newToken(u.account.Name),
+		"modhash":    modhash(u),
+		"need_https": false,
+	})
+}
+
+// serveAccessToken issues OAuth tokens using the password grant
+func (s *Server) serveAccessToken(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.users[r.Form.Get("username")]
+	if r.Form.Get("grant_type") != "password" || !ok || u.password != r.Form.Get("password") {
+		writeJSON(w, http.StatusOK, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": s.newToken(u.account.Name),
+		"expires_in":   3600,
+		"scope":        "*",
+		"token_type":   "bearer",
+	})
+}
+
+func (s *Server) serveMe(w http.ResponseWriter, r *http.Request) {
+	u := s.authenticated(r)
+	if u == nil {
+		// Reddit replies with an empty object to anonymous requests
+		writeJSON(w, http.StatusOK, struct{}{})
+		return
+	}
+	acct := u.account
+	acct.Modhash = modhash(u)
+	writeJSON(w, http.StatusOK, thing(rego.TypeAccount, acct))
+}
+
+func (s *Server) serveUser(w http.ResponseWriter, name string) {
+	u, ok := s.users[name]
+	if !ok {
+		writeStatus(w, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, thing(rego.TypeAccount, u.account))
+}
+
+func (s *Server) serveSubreddit(w http.ResponseWriter, name string) {
+	sub, ok := s.subs[name]
+	if !ok {
+		writeStatus(w, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, thing(rego.TypeSubreddit, sub))
+}
+
+func (s *Server) serveInfo(w http.ResponseWriter, r *http.Request) {
+	var items []item
+	for _, id := range strings.Split(r.Form.Get("id"), ",") {
+		name := rego.Fullname(id)
+		if l := s.link(name); l != nil {
+			items = append(items, item{rego.TypeLink, l.Name, l})
+		} else if c := s.comment(name); c != nil {
+			items = append(items, item{rego.TypeComment, c.Name, c})
+		}
+	}
+	writeListing(w, r, items)
+}
+
+func (s *Server) serveLinks(w http.ResponseWriter, r *http.Request, sub string, order string) {
+	if _, ok := s.subs[sub]; !ok {
+		writeStatus(w, http.StatusNotFound)
+		return
+	}
+	var links []*rego.Link
+	for _, l := range s.links {
+		if l.Subreddit == sub {
+			links = append(links, l)
+		}
+	}
+	writeListing(w, r, linkItems(sortLinks(links, order)))
+}
+
+func (s *Server) serveUserLinks(w http.ResponseWriter, r *http.Request, name string) {
+	if _, ok := s.users[name]; !ok {
+		writeStatus(w, http.StatusNotFound)
+		return
+	}
+	var links []*rego.Link
+	for _, l := range s.links {
+		if l.Author == name {
+			links = append(links, l)
+		}
+	}
+	writeListing(w, r, linkItems(sortLinks(links, rego.SortNew)))
+}
+
+func (s *Server) serveSubredditComments(w http.ResponseWriter, r *http.Request, sub string) {
+	if _, ok := s.subs[sub]; !ok {
+		writeStatus(w, http.StatusNotFound)
+		return
+	}
+	s.serveComments(w, r, func(c *rego.Comment) bool { return c.Subreddit == sub })
+}
+
+func (s *Server) serveUserComments(w http.ResponseWriter, r *http.Request, name string) {
+	if _, ok := s.users[name]; !ok {
+		writeStatus(w, http.StatusNotFound)
+		return
+	}
+	s.serveComments(w, r, func(c *rego.Comment) bool { return c.Author == name })
+}
+
+// serveComments lists the comments matching filter, newest first
+func (s *Server) serveComments(w http.ResponseWriter, r *http.Request, filter func(*rego.Comment) bool) {
+	var items []item
+	for i := len(s.comments) - 1; i >= 0; i-- {
+		if c := s.comments[i]; filter(c) {
+			items = append(items, item{rego.TypeComment, c.Name, c})
+		}
+	}
+	writeListing(w, r, items)
+}
+
+func (s *Server) serveComment(w http.ResponseWriter, r *http.Request) {
+	u := s.authenticated(r)
+	if u == nil {
+		writeErrors(w, []string{string(rego.ErrUserRequired), "Please log in to do that.", ""})
+		return
+	}
+	text := r.Form.Get("text")
+	if len(strings.TrimSpace(text)) == 0 {
+		writeErrors(w, []string{string(rego.ErrNoText), "we need something here", "text"})
+		return
+	}
+
+	parent := rego.Fullname(r.Form.Get("thing_id"))
+	c := s.addComment(parent, rego.Comment{
+		Author:   u.account.Name,
+		Body:     text,
+		BodyHTML: html.EscapeString(text),
+	})
+	if c == nil {
+		code := rego.ErrDeletedLink
+		if parent.Kind() == rego.TypeComment {
+			code = rego.ErrDeletedComment
+		}
+		writeErrors(w, []string{string(code), "that item has been deleted", "parent"})
+		return
+	}
+
+	writeAPI(w, map[string]interface{}{
+		"things": []interface{}{thing(rego.TypeComment, map[string]string{
+			"contentHTML": c.BodyHTML,
+			"contentText": c.Body,
+			"id":          c.Name,
+			"link":        c.LinkID,
+			"parent":      c.ParentID,
+			"replies":     "",
+		})},
+	})
+}
+
+func (s *Server) serveSubmit(w http.ResponseWriter, r *http.Request) {
+	u := s.authenticated(r)
+	if u == nil {
+		writeErrors(w, []string{string(rego.ErrUserRequired), "Please log in to do that.", ""})
+		return
+	}
+	sub := r.Form.Get("sr")
+	if _, ok := s.subs[sub]; !ok {
+		writeErrors(w, []string{string(rego.ErrSubredditNoExist), "that subreddit doesn't exist", "sr"})
+		return
+	}
+	title := r.Form.Get("title")
+	if len(strings.TrimSpace(title)) == 0 {
+		writeErrors(w, []string{string(rego.ErrNoText), "we need something here", "title"})
+		return
+	}
+
+	l := rego.Link{
+		Author: u.account.Name,
+		Title:  title,
+	}
+	switch r.Form.Get("kind") {
+	case "link":
+		l.URL = r.Form.Get("url")
+		if !strings.HasPrefix(l.URL, "http://") && !strings.HasPrefix(l.URL, "https://") {
+			writeErrors(w, []string{string(rego.ErrBadURL), "you should check that url", "url"})
+			return
+		}
+		l.Domain = l.URL[strings.Index(l.URL, "//")+2:]
+		if i := strings.IndexAny(l.Domain, "/?#"); i >= 0 {
+			l.Domain = l.Domain[:i]
+		}
+	case "self":
+		l.Selftext = r.Form.Get("text")
+		l.SelftextHTML = html.EscapeString(l.Selftext)
+	default:
+		writeErrors(w, []string{string(rego.ErrInvalidOption), "that option is not valid", "kind"})
+		return
+	}
+
+	stored := s.addLink(sub, l)
+	writeAPI(w, map[string]string{
+		"id":   stored.ID,
+		"name": stored.Name,
+		"url":  "https://www.reddit.com" + stored.Permalink,
+	})
+}
+
+func (s *Server) serveDelete(w http.ResponseWriter, r *http.Request) {
+	u := s.authenticated(r)
+	if u == nil {
+		writeErrors(w, []string{string(rego.ErrUserRequired), "Please log in to do that.", ""})
+		return
+	}
+
+	id := rego.Fullname(r.Form.Get("id"))
+	if l := s.link(id); l != nil && l.Author == u.account.Name {
+		l.Author = deleted
+		if l.Selfpost {
+			l.Selftext, l.SelftextHTML = deleted, deleted
+		}
+	}
+	if c := s.comment(id); c != nil && c.Author == u.account.Name {
+		c.Author = deleted
+		c.Body, c.BodyHTML = deleted, deleted
+	}
+	// Reddit replies with an empty object whether or not anything was deleted
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+func linkItems(links []*rego.Link) []item {
+	var items []item
+	for _, l := range links {
+		items = append(items, item{rego.TypeLink, l.Name, l})
+	}
+	return items
+}
+
+// writeListing writes the page of items selected by the after, before
+// and limit parameters of r as a Listing
+func writeListing(w http.ResponseWriter, r *http.Request, items []item) {
+	limit, err := strconv.Atoi(r.Form.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > rego.MaxLimit {
+		limit = rego.MaxLimit
+	}
+
+	index := func(name string) int {
+		for i, it := range items {
+			if it.name == name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	start, end := 0, len(items)
+	if after := r.Form.Get("after"); len(after) > 0 {
+		start = index(after) + 1
+		if start == 0 {
+			start = len(items)
+		}
+	} else if before := r.Form.Get("before"); len(before) > 0 {
+		end = index(before)
+		if end < 0 {
+			end = 0
+		}
+		if end-limit > 0 {
+			start = end - limit
+		}
+	}
+	if end-start > limit {
+		end = start + limit
+	}
+
+	var after, before *string
+	if end < len(items) && end > 0 {
+		after = &items[end-1].name
+	}
+	if start > 0 && start < len(items) {
+		before = &items[start].name
+	}
+
+	children := []interface{}{}
+	for _, it := range items[start:end] {
+		children = append(children, thing(it.kind, it.data))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"kind": rego.TypeListing,
+		"data": map[string]interface{}{
+			"after":    after,
+			"before":   before,
+			"children": children,
+			"modhash":  "",
+		},
+	})
+}
+
+// writeAPI writes a successful JSON API reply holding data
+func writeAPI(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"json": map[string]interface{}{
+			"data":   data,
+			"errors": [][]string{},
+		},
+	})
+}
+
+// writeErrors writes a JSON API reply reporting errors, each a
+// [code, message, field] triplet
+func writeErrors(w http.ResponseWriter, errors ...[]string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"json": map[string]interface{}{
+			"errors": errors,
+		},
+	})
+}
+
+func writeFault(w http.ResponseWriter, f Fault) {
+	for k, v := range f.Header {
+		w.Header()[k] = v
+	}
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if len(f.Errors) > 0 {
+		writeJSON(w, status, map[string]interface{}{
+			"json": map[string]interface{}{
+				"errors": f.Errors,
+			},
+		})
+		return
+	}
+	writeStatus(w, status)
+}
+
+// writeStatus writes a reply with the given status, formatted like the
+// error replies of Reddit
+func writeStatus(w http.ResponseWriter, status int) {
+	writeJSON(w, status, map[string]interface{}{
+		"error":   status,
+		"message": http.StatusText(status),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func thing(kind string, data interface{}) map[string]interface{} {
+	return map[string]interface{}{"kind": kind, "data": data}
+}
+
+func modhash(u *user) string {
+	return "mh" + u.account.ID
+}
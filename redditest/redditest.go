@@ -0,0 +1,343 @@
+// Package redditest provides an in-process fake Reddit for testing code
+// using rego.
+//
+// A Server emulates the endpoints used by rego, backed by an in-memory
+// store that tests seed with users, subreddits, links and comments:
+//
+//	srv := redditest.NewServer()
+//	defer srv.Close()
+//	srv.AddUser("bob", "hunter2")
+//	srv.AddSubreddit("golang")
+//	srv.AddLink("golang", rego.Link{Title: "Hello"})
+//
+//	s := rego.NewSession("TestBot/1.0")
+//	s.SetClient(srv.Client())
+//
+// Failures and rate limiting are simulated by injecting a Fault.
+package redditest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/c0rner/rego"
+)
+
+// Fault is a failure injected into the reply of a request
+type Fault struct {
+	Errors [][]string  // Reddit API errors, e.g. {"RATELIMIT", "you are doing that too much", "ratelimit"}
+	Header http.Header // Headers added to the reply
+	Status int         // HTTP status code, 200 if zero
+}
+
+// user is an account of the store
+type user struct {
+	account  rego.Account
+	password string
+}
+
+// Server is a fake Reddit server. All methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	lock      sync.Mutex
+	comments  []*rego.Comment // In order of creation
+	faults    map[string][]Fault
+	links     []*rego.Link // In order of creation
+	nextID    int64
+	rateLimit http.Header
+	sessions  map[string]string // Session cookies and OAuth tokens, by account name
+	subs      map[string]*rego.Subreddit
+	users     map[string]*user
+}
+
+// NewServer starts and returns a new Server with an empty store.
+// The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		faults:   map[string][]Fault{},
+		nextID:   1000,
+		sessions: map[string]string{},
+		subs:     map[string]*rego.Subreddit{},
+		users:    map[string]*user{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client returns an HTTP client sending all requests, regardless of host,
+// to the server. Use it with rego.Session.SetClient.
+func (s *Server) Client() *http.Client {
+	target, _ := url.Parse(s.URL)
+	return &http.Client{Transport: rewriteTransport{target}}
+}
+
+// rewriteTransport sends all requests to the server at target
+type rewriteTransport struct {
+	target *url.URL
+}
+
+// RoundTrip rewrites the scheme and host of req before sending it
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	r.Host = ""
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// Inject makes the next request to path, e.g. "/api/comment", fail with f.
+// An empty path matches any request. Faults of a path are used in the order
+// injected, each only once.
+func (s *Server) Inject(path string, f Fault) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.faults[path] = append(s.faults[path], f)
+}
+
+// InjectRateLimit makes the next request to path fail with status 429,
+// as Reddit replies when the rate limit is exceeded
+func (s *Server) InjectRateLimit(path string, reset time.Duration) {
+	h := http.Header{}
+	h.Set("X-Ratelimit-Remaining", "0")
+	h.Set("X-Ratelimit-Reset", strconv.Itoa(int(reset/time.Second)))
+	s.Inject(path, Fault{Header: h, Status: http.StatusTooManyRequests})
+}
+
+// SetRateLimit sets the rate limit headers sent with every reply
+func (s *Server) SetRateLimit(used int, remaining int, reset time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.rateLimit = http.Header{}
+	s.rateLimit.Set("X-Ratelimit-Used", strconv.Itoa(used))
+	s.rateLimit.Set("X-Ratelimit-Remaining", strconv.Itoa(remaining))
+	s.rateLimit.Set("X-Ratelimit-Reset", strconv.Itoa(int(reset/time.Second)))
+}
+
+// AddUser adds an account with the given name and password
+func (s *Server) AddUser(name string, password string) rego.Account {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	acct := rego.Account{
+		ID:   s.newID(),
+		Name: name,
+	}
+	acct.Created = created(time.Now())
+	s.users[name] = &user{account: acct, password: password}
+	return acct
+}
+
+// AddSubreddit adds a public subreddit with the given name
+func (s *Server) AddSubreddit(name string) rego.Subreddit {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return *s.addSubreddit(name)
+}
+
+// AddLink adds link l to subreddit sub, which is created if needed. The
+// identifiers, subreddit, permalink and creation time are filled in unless
+// set. The stored link is returned.
+func (s *Server) AddLink(sub string, l rego.Link) rego.Link {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return *s.addLink(sub, l)
+}
+
+// AddComment adds comment c as a reply to the link or comment parent. The
+// identifiers, link, subreddit and creation time are filled in unless set.
+// False is returned if parent does not exist.
+func (s *Server) AddComment(parent rego.Fullname, c rego.Comment) (rego.Comment, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stored := s.addComment(parent, c)
+	if stored == nil {
+		return rego.Comment{}, false
+	}
+	return *stored, true
+}
+
+// Link returns the stored link with fullname id
+func (s *Server) Link(id rego.Fullname) (rego.Link, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if l := s.link(id); l != nil {
+		return *l, true
+	}
+	return rego.Link{}, false
+}
+
+// Comment returns the stored comment with fullname id
+func (s *Server) Comment(id rego.Fullname) (rego.Comment, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if c := s.comment(id); c != nil {
+		return *c, true
+	}
+	return rego.Comment{}, false
+}
+
+// Comments returns the stored comments replying to parent, oldest first
+func (s *Server) Comments(parent rego.Fullname) []rego.Comment {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var comments []rego.Comment
+	for _, c := range s.comments {
+		if c.ParentID == string(parent) {
+			comments = append(comments, *c)
+		}
+	}
+	return comments
+}
+
+func (s *Server) addSubreddit(name string) *rego.Subreddit {
+	sub := rego.Subreddit{
+		DisplayName:         name,
+		DisplayNamePrefixed: "r/" + name,
+		ID:                  s.newID(),
+		SubredditType:       "public",
+		Title:               name,
+		URL:                 "/r/" + name + "/",
+	}
+	sub.Name = string(rego.NewFullname(rego.TypeSubreddit, sub.ID))
+	sub.Created = created(time.Now())
+	s.subs[name] = &sub
+	return &sub
+}
+
+func (s *Server) addLink(sub string, l rego.Link) *rego.Link {
+	if _, ok := s.subs[sub]; !ok {
+		s.addSubreddit(sub)
+	}
+	if len(l.ID) == 0 {
+		l.ID = s.newID()
+	}
+	if len(l.Name) == 0 {
+		l.Name = string(rego.NewFullname(rego.TypeLink, l.ID))
+	}
+	l.Subreddit = sub
+	l.SubredditID = s.subs[sub].Name
+	if len(l.Permalink) == 0 {
+		l.Permalink = "/r/" + sub + "/comments/" + l.ID + "/"
+	}
+	if len(l.URL) == 0 {
+		l.URL = "https://www.reddit.com" + l.Permalink
+		l.Selfpost = true
+		l.Domain = "self." + sub
+	}
+	if l.Created.UTC.IsZero() {
+		l.Created = created(time.Now())
+	}
+	s.links = append(s.links, &l)
+	return &l
+}
+
+func (s *Server) addComment(parent rego.Fullname, c rego.Comment) *rego.Comment {
+	var l *rego.Link
+	switch parent.Kind() {
+	case rego.TypeLink:
+		l = s.link(parent)
+	case rego.TypeComment:
+		if p := s.comment(parent); p != nil {
+			l = s.link(p.Link())
+		}
+	}
+	if l == nil {
+		return nil
+	}
+
+	if len(c.ID) == 0 {
+		c.ID = s.newID()
+	}
+	if len(c.Name) == 0 {
+		c.Name = string(rego.NewFullname(rego.TypeComment, c.ID))
+	}
+	c.LinkAuthor = l.Author
+	c.LinkID = l.Name
+	c.LinkTitle = l.Title
+	c.LinkURL = l.URL
+	c.ParentID = string(parent)
+	c.Subreddit = l.Subreddit
+	c.SubredditID = l.SubredditID
+	if c.Created.UTC.IsZero() {
+		c.Created = created(time.Now())
+	}
+	l.NumComments++
+	s.comments = append(s.comments, &c)
+	return &c
+}
+
+func (s *Server) link(id rego.Fullname) *rego.Link {
+	for _, l := range s.links {
+		if l.Name == string(id) {
+			return l
+		}
+	}
+	return nil
+}
+
+func (s *Server) comment(id rego.Fullname) *rego.Comment {
+	for _, c := range s.comments {
+		if c.Name == string(id) {
+			return c
+		}
+	}
+	return nil
+}
+
+// newID returns a new unique base36 item identifier
+func (s *Server) newID() string {
+	s.nextID++
+	return strconv.FormatInt(s.nextID, 36)
+}
+
+// fault returns the next fault injected for path, if any
+func (s *Server) fault(path string) (Fault, bool) {
+	for _, p := range []string{path, ""} {
+		if faults := s.faults[p]; len(faults) > 0 {
+			s.faults[p] = faults[1:]
+			return faults[0], true
+		}
+	}
+	return Fault{}, false
+}
+
+// newToken returns a new session cookie or OAuth token for account name
+func (s *Server) newToken(name string) string {
+	token := name + "," + s.newID()
+	s.sessions[token] = name
+	return token
+}
+
+// sortLinks returns links ordered by sort order, e.g. rego.SortNew
+func sortLinks(links []*rego.Link, order string) []*rego.Link {
+	sorted := make([]*rego.Link, len(links))
+	// Newest first
+	for i, l := range links {
+		sorted[len(links)-1-i] = l
+	}
+	switch order {
+	case rego.SortTop, rego.SortHot, "":
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Score > sorted[j].Score
+		})
+	case rego.SortControversial:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Score < sorted[j].Score
+		})
+	}
+	return sorted
+}
+
+func created(t time.Time) rego.Created {
+	t = t.Truncate(time.Second)
+	return rego.Created{
+		Local: rego.Timestamp{Time: t},
+		UTC:   rego.Timestamp{Time: t},
+	}
+}
@@ -0,0 +1,239 @@
+package redditest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/c0rner/rego"
+)
+
+func newSession(srv *Server) *rego.Session {
+	s := rego.NewSession("RedditestBot/1.0")
+	s.SetClient(srv.Client())
+	return s
+}
+
+func TestLogin(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddUser("bob", "hunter2")
+	s := newSession(srv)
+
+	err := s.Login("bob", "wrong")
+	if !errors.Is(err, rego.ErrWrongPassword) {
+		t.Errorf("Got %v, wanted ErrWrongPassword", err)
+	}
+	err = s.Login("bob", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	me, err := s.Me()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if me.Name != "bob" || len(me.Modhash) == 0 {
+		t.Errorf("Got %#v, wanted bob with a modhash", me)
+	}
+
+	u, err := s.User("alice")
+	if !errors.Is(err, rego.ErrNotFound) {
+		t.Errorf("Got %v, %v, wanted ErrNotFound", u, err)
+	}
+}
+
+func TestAccessToken(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddUser("bob", "hunter2")
+
+	v := url.Values{"grant_type": {"password"}, "username": {"bob"}, "password": {"hunter2"}}
+	resp, err := srv.Client().PostForm("https://www.reddit.com/api/v1/access_token", v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reply := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	decode(t, resp, &reply)
+
+	req, _ := http.NewRequest("GET", "https://oauth.reddit.com/api/me.json", nil)
+	req.Header.Set("Authorization", "bearer "+reply.AccessToken)
+	resp, err = srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thing := rego.Thing{}
+	decode(t, resp, &thing)
+	if thing.Kind != rego.TypeAccount {
+		t.Errorf("Got kind %q, wanted an account", thing.Kind)
+	}
+}
+
+func TestListing(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	for i := 0; i < 5; i++ {
+		srv.AddLink("golang", rego.Link{Title: string(rune('a' + i))})
+	}
+	s := newSession(srv)
+
+	page := s.Listing("r/golang/new")
+	page.SetLimit(2)
+	var titles []string
+	for i := 0; i < 3; i++ {
+		list, err := page.Previous()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, l := range list.Links() {
+			titles = append(titles, l.Title)
+		}
+	}
+	if strings.Join(titles, "") != "edcba" {
+		t.Errorf("Got %q, wanted newest first", titles)
+	}
+
+	list, err := page.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links := list.Links(); len(links) != 2 || links[0].Title != "c" {
+		t.Errorf("Got %#v, wanted the two links preceding the last page", links)
+	}
+}
+
+func TestComment(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddUser("bob", "hunter2")
+	l := srv.AddLink("golang", rego.Link{Title: "Hello"})
+	s := newSession(srv)
+
+	_, err := s.Comment(rego.Fullname(l.Name), "Hi")
+	if !errors.Is(err, rego.ErrUserRequired) {
+		t.Errorf("Got %v, wanted ErrUserRequired", err)
+	}
+
+	err = s.Login("bob", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr, err := s.Comment(rego.Fullname(l.Name), "Hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, ok := srv.Comment(rego.Fullname(cr.ID))
+	if !ok || c.Body != "Hi" || c.Author != "bob" || c.Link() != rego.Fullname(l.Name) {
+		t.Errorf("Got %#v, wanted the posted comment", c)
+	}
+	if stored, _ := srv.Link(rego.Fullname(l.Name)); stored.NumComments != 1 {
+		t.Errorf("Got %d comments, wanted 1", stored.NumComments)
+	}
+
+	list, err := s.Info(rego.Fullname(cr.ID), rego.Fullname(l.Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Comments()) != 1 || len(list.Links()) != 1 {
+		t.Errorf("Got %d items, wanted the comment and link", len(list.Data.Children))
+	}
+}
+
+func TestSubmitDelete(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddUser("bob", "hunter2")
+	srv.AddSubreddit("golang")
+	s := newSession(srv)
+	err := s.Login("bob", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := srv.Client()
+	header := func(req *http.Request) {
+		req.Header.Set("Cookie", s.Cookie())
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	v := url.Values{"api_type": {"json"}, "sr": {"golang"}, "kind": {"self"}, "title": {"Hi"}, "text": {"Body"}}
+	req, _ := http.NewRequest("POST", "https://www.reddit.com/api/submit", strings.NewReader(v.Encode()))
+	header(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := struct {
+		JSON struct {
+			Data struct {
+				Name string `json:"name"`
+			} `json:"data"`
+		} `json:"json"`
+	}{}
+	decode(t, resp, &reply)
+	name := rego.Fullname(reply.JSON.Data.Name)
+	if l, ok := srv.Link(name); !ok || l.Selftext != "Body" || l.Author != "bob" {
+		t.Fatalf("Got %#v, wanted the submitted link", l)
+	}
+
+	v = url.Values{"id": {string(name)}}
+	req, _ = http.NewRequest("POST", "https://www.reddit.com/api/del", strings.NewReader(v.Encode()))
+	header(req)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if l, _ := srv.Link(name); l.Author != "[deleted]" {
+		t.Errorf("Got author %q, wanted the link deleted", l.Author)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddSubreddit("golang")
+	srv.SetRateLimit(10, 590, time.Minute)
+	s := newSession(srv)
+
+	srv.InjectRateLimit("/r/golang/about.json", 30*time.Second)
+	srv.Inject("", Fault{Status: http.StatusInternalServerError})
+
+	_, err := s.Subreddit("golang")
+	if !errors.Is(err, rego.ErrRateLimited) {
+		t.Errorf("Got %v, wanted ErrRateLimited", err)
+	}
+	_, err = s.Subreddit("golang")
+	if !errors.Is(err, rego.ErrServerError) {
+		t.Errorf("Got %v, wanted ErrServerError", err)
+	}
+	sub, err := s.Subreddit("golang")
+	if err != nil || sub.DisplayName != "golang" {
+		t.Errorf("Got %v, %v, wanted golang", sub, err)
+	}
+
+	srv.Inject("/api/login", Fault{Errors: [][]string{{"RATELIMIT", "you are doing that too much", "ratelimit"}}})
+	err = s.Login("bob", "hunter2")
+	if !errors.Is(err, rego.ErrRateLimited) {
+		t.Errorf("Got %v, wanted ErrRateLimited", err)
+	}
+	if rl := s.RateLimit(); rl.Remaining != 590 || rl.Used != 10 || rl.Reset != 60 {
+		t.Errorf("Got %+v, wanted the configured rate limit", rl)
+	}
+}
+
+func decode(t *testing.T, resp *http.Response, v interface{}) {
+	t.Helper()
+	defer resp.Body.Close()
+	err := json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
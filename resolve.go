@@ -0,0 +1,252 @@
+package rego
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrUnknownURL = errors.New("unrecognised reddit url")
+)
+
+// URLKind describes what a Reddit URL points to
+type URLKind int
+
+// Reddit URL kinds
+const (
+	URLUnknown    URLKind = iota
+	URLComment            // A comment of a submission
+	URLLive               // A live thread
+	URLMulti              // A multireddit of a user
+	URLShare              // A share link, must be resolved by following its redirect
+	URLSubmission         // A submission, i.e. a link or selfpost
+	URLSubreddit          // A subreddit
+	URLUser               // A user profile
+	URLWiki               // A subreddit wiki page
+)
+
+// RedditURL is a classified Reddit URL with its identifiers extracted
+type RedditURL struct {
+	Comment    Fullname // Comment fullname of URLComment
+	Kind       URLKind  // What the URL points to
+	Link       Fullname // Submission fullname of URLSubmission and URLComment
+	LiveThread string   // Thread identifier of URLLive
+	Multi      string   // Multireddit name of URLMulti
+	Subreddit  string   // Subreddit name, if part of the URL
+	URL        string   // The URL as parsed
+	User       string   // Account name of URLUser and URLMulti
+	WikiPage   string   // Wiki page name of URLWiki
+}
+
+// ParseURL classifies a reddit.com or redd.it URL and extracts its
+// identifiers. Relative URLs, e.g. "/r/golang", are accepted.
+func ParseURL(s string) (*RedditURL, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	// Accept scheme-less URLs such as "redd.it/abc"
+	if len(u.Host) == 0 && !strings.HasPrefix(u.Path, "/") {
+		u, err = url.Parse("https://" + strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	host := strings.ToLower(u.Hostname())
+	ru := RedditURL{URL: s}
+	switch {
+	case host == "redd.it":
+		id := strings.Trim(u.Path, "/")
+		if !isBase36(id) {
+			return nil, ErrUnknownURL
+		}
+		ru.Kind = URLSubmission
+		ru.Link = NewFullname(TypeLink, strings.ToLower(id))
+		return &ru, nil
+	case len(host) == 0, host == "reddit.com", strings.HasSuffix(host, ".reddit.com"):
+	default:
+		return nil, ErrUnknownURL
+	}
+
+	if !parsePath(&ru, u.Path) {
+		return nil, ErrUnknownURL
+	}
+	return &ru, nil
+}
+
+// parsePath classifies ru by URL path p, returning false if unrecognised
+func parsePath(ru *RedditURL, p string) bool {
+	var parts []string
+	for _, part := range strings.Split(p, "/") {
+		if len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return false
+	}
+
+	switch strings.ToLower(parts[0]) {
+	case "r":
+		if len(parts) < 2 {
+			return false
+		}
+		ru.Subreddit = parts[1]
+		ru.Kind = URLSubreddit
+		if len(parts) == 2 {
+			return true
+		}
+		switch parts[2] {
+		case "comments":
+			return parseComments(ru, parts[3:])
+		case "s":
+			ru.Kind = URLShare
+			return len(parts) > 3
+		case "wiki":
+			ru.Kind = URLWiki
+			ru.WikiPage = "index"
+			if len(parts) > 3 {
+				ru.WikiPage = strings.Join(parts[3:], "/")
+			}
+		}
+		// Anything else, e.g. /r/golang/new, is a subreddit listing
+		return true
+	case "comments", "gallery":
+		return parseComments(ru, parts[1:])
+	case "u", "user":
+		if len(parts) < 2 {
+			return false
+		}
+		ru.User = parts[1]
+		ru.Kind = URLUser
+		if len(parts) < 4 {
+			return true
+		}
+		switch parts[2] {
+		case "comments":
+			return parseComments(ru, parts[3:])
+		case "m":
+			ru.Kind = URLMulti
+			ru.Multi = parts[3]
+		case "s":
+			ru.Kind = URLShare
+		}
+		return true
+	case "live":
+		if len(parts) < 2 {
+			return false
+		}
+		ru.Kind = URLLive
+		ru.LiveThread = parts[1]
+		return true
+	}
+	return false
+}
+
+// parseComments parses the {id}/{slug}/{comment} part of a comments URL
+func parseComments(ru *RedditURL, parts []string) bool {
+	if len(parts) == 0 || !isBase36(parts[0]) {
+		return false
+	}
+	ru.Kind = URLSubmission
+	ru.Link = NewFullname(TypeLink, strings.ToLower(parts[0]))
+	if len(parts) > 2 && isBase36(parts[2]) {
+		ru.Kind = URLComment
+		ru.Comment = NewFullname(TypeComment, strings.ToLower(parts[2]))
+	}
+	return true
+}
+
+func isBase36(s string) bool {
+	return len(s) > 0 && NewFullname(TypeLink, strings.ToLower(s)).Validate() == nil
+}
+
+// Resolve fetches the item URL u points to. Depending on the kind of URL
+// the result is one of *Link, *Comment, *Subreddit, *Account, *WikiPage,
+// *Multireddit or *LiveThread. Share links are resolved by following
+// their redirect.
+func (s *Session) Resolve(u string) (interface{}, error) {
+	ru, err := ParseURL(u)
+	if err != nil {
+		return nil, err
+	}
+
+	if ru.Kind == URLShare {
+		ru, err = s.followShare(ru.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch ru.Kind {
+	case URLComment:
+		return s.infoItem(ru.Comment)
+	case URLSubmission:
+		return s.infoItem(ru.Link)
+	case URLSubreddit:
+		return s.Subreddit(ru.Subreddit)
+	case URLUser:
+		return s.User(ru.User)
+	case URLWiki:
+		return s.WikiPage(ru.Subreddit, ru.WikiPage)
+	case URLMulti:
+		return s.Multi(ru.User, ru.Multi)
+	case URLLive:
+		return s.LiveThread(ru.LiveThread)
+	}
+	return nil, ErrUnknownURL
+}
+
+// followShare requests share link u and classifies the URL it redirects to
+func (s *Session) followShare(u string) (*RedditURL, error) {
+	su, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.newRequest("GET", s.url(su.Path), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	err = decodeReply(resp, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Middleware may reply without setting the request
+	final := req.URL
+	if resp.Request != nil {
+		final = resp.Request.URL
+	}
+	ru := RedditURL{URL: final.String()}
+	if !parsePath(&ru, final.Path) || ru.Kind == URLShare {
+		return nil, ErrUnknownURL
+	}
+	return &ru, nil
+}
+
+// infoItem fetches the single link or comment id
+func (s *Session) infoItem(id Fullname) (interface{}, error) {
+	list, err := s.Info(id)
+	if err != nil {
+		return nil, err
+	}
+	things, err := list.Decode()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range things {
+		switch item := t.Value.(type) {
+		case Link:
+			return &item, nil
+		case Comment:
+			return &item, nil
+		}
+	}
+	return nil, ErrNotFound
+}
@@ -0,0 +1,101 @@
+package rego
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	var tests = []struct {
+		url    string
+		result RedditURL
+	}{
+		{"https://www.reddit.com/r/golang/", RedditURL{Kind: URLSubreddit, Subreddit: "golang"}},
+		{"https://old.reddit.com/r/golang/top/?t=week", RedditURL{Kind: URLSubreddit, Subreddit: "golang"}},
+		{"/r/golang", RedditURL{Kind: URLSubreddit, Subreddit: "golang"}},
+		{"https://www.reddit.com/r/x/comments/abc/title/", RedditURL{Kind: URLSubmission, Subreddit: "x", Link: "t3_abc"}},
+		{"https://www.reddit.com/r/x/comments/abc/title/def/", RedditURL{Kind: URLComment, Subreddit: "x", Link: "t3_abc", Comment: "t1_def"}},
+		{"https://reddit.com/comments/abc", RedditURL{Kind: URLSubmission, Link: "t3_abc"}},
+		{"https://www.reddit.com/gallery/abc", RedditURL{Kind: URLSubmission, Link: "t3_abc"}},
+		{"https://redd.it/abc", RedditURL{Kind: URLSubmission, Link: "t3_abc"}},
+		{"redd.it/abc", RedditURL{Kind: URLSubmission, Link: "t3_abc"}},
+		{"https://www.reddit.com/r/x/s/AbC123", RedditURL{Kind: URLShare, Subreddit: "x"}},
+		{"https://www.reddit.com/user/bob", RedditURL{Kind: URLUser, User: "bob"}},
+		{"https://www.reddit.com/u/bob/comments/abc/title", RedditURL{Kind: URLSubmission, User: "bob", Link: "t3_abc"}},
+		{"https://www.reddit.com/user/bob/m/news", RedditURL{Kind: URLMulti, User: "bob", Multi: "news"}},
+		{"https://www.reddit.com/r/x/wiki/", RedditURL{Kind: URLWiki, Subreddit: "x", WikiPage: "index"}},
+		{"https://www.reddit.com/r/x/wiki/config/bot", RedditURL{Kind: URLWiki, Subreddit: "x", WikiPage: "config/bot"}},
+		{"https://www.reddit.com/live/xyz/updates/123", RedditURL{Kind: URLLive, LiveThread: "xyz"}},
+	}
+
+	for _, test := range tests {
+		ru, err := ParseURL(test.url)
+		if err != nil {
+			t.Errorf("%s: %s", test.url, err)
+			continue
+		}
+		test.result.URL = test.url
+		if *ru != test.result {
+			t.Errorf("%s: got %+v, wanted %+v", test.url, *ru, test.result)
+		}
+	}
+
+	for _, u := range []string{"https://example.com/r/x", "https://www.reddit.com/", "https://www.reddit.com/r/x/comments/no-id!"} {
+		if _, err := ParseURL(u); err != ErrUnknownURL {
+			t.Errorf("%s: got %v, wanted ErrUnknownURL", u, err)
+		}
+	}
+}
+
+func TestResolveShare(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/r/x/s/AbC123":
+			http.Redirect(w, r, "/r/x/comments/abc/title/def/?share_id=1", http.StatusMovedPermanently)
+		case "/r/x/comments/abc/title/def/":
+			fmt.Fprint(w, `<html></html>`)
+		case apiInfo:
+			if r.FormValue("id") != "t1_def" {
+				t.Errorf("Got id %q, wanted t1_def", r.FormValue("id"))
+			}
+			fmt.Fprint(w, `{"kind": "Listing", "data": {"children": [{"kind": "t1", "data": {"name": "t1_def", "body": "shared"}}]}}`)
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	item, err := s.Resolve("https://www.reddit.com/r/x/s/AbC123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := item.(*Comment); !ok || c.Body != "shared" {
+		t.Errorf("Got %#v, wanted *Comment", item)
+	}
+}
+
+func TestResolveShareBareResponse(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Unexpected request %s", r.URL.Path)
+	}))
+	defer ts.Close()
+	// Middleware replying without setting the request of the response
+	s.Use(func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				Body:       io.NopCloser(strings.NewReader("<html></html>")),
+				Header:     http.Header{},
+				StatusCode: http.StatusOK,
+			}, nil
+		})
+	})
+
+	_, err := s.Resolve("https://www.reddit.com/r/x/s/AbC123")
+	if !errors.Is(err, ErrUnknownURL) {
+		t.Errorf("Got %v, wanted ErrUnknownURL", err)
+	}
+}
@@ -1,18 +1,23 @@
 package rego
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 var (
-	ErrBadCookie = errors.New("bad cookie")
+	ErrBadCookie      = errors.New("bad cookie")
+	ErrEmptyReply     = errors.New("empty reply")
+	ErrUnexpectedKind = errors.New("unexpected thing kind")
 )
 
 // RateLimit provides access to the Reddit ratelimit values for a session
@@ -22,110 +27,178 @@ type RateLimit struct {
 	Used      int
 }
 
+// DefaultConcurrency is the default limit of parallel requests of a session
+const DefaultConcurrency = 4
+
 // Session is an active Reddit session that initially is unauthenticated. An authenticated
 // session can be set up using Session.Login or Session.SetCookie.
+//
+// Sessions are safe for concurrent use by multiple goroutines, with up to
+// SetConcurrency requests in flight at a time. Configuration methods, i.e.
+// SetCache, SetCacheTTL, SetClient, SetConcurrency, SetEndpointTLS,
+// SetInstrumentation, SetLogger, SetTLSPolicy and Use, must be called before
+// the session is used concurrently. A Page must not be used concurrently.
 type Session struct {
-	client    *http.Client
-	Cookie    string // Session cookie (empty if not logged in)
-	modhash   string
-	RateLimit RateLimit // RateLimit usage is updated on each API request
-	useragent string
-	lock      sync.Mutex
+	cache           Cache
+	cacheTTL        time.Duration
+	cacheTTLs       []cacheTTL
+	client          *http.Client
+	cookie          string // Session cookie (empty if not logged in)
+	endpointTLS     []endpointTLS
+	instrumentation Instrumentation
+	logger          *slog.Logger
+	logLevel        slog.Level
+	middleware      []Middleware
+	modhash         string
+	rateLimit       RateLimit     // RateLimit usage is updated on each API request
+	sem             chan struct{} // Limits the requests in flight
+	tlsPolicy       TLSPolicy
+	useragent       string
+	lock            sync.RWMutex // Guards cookie, modhash and rateLimit
 }
 
 // NewSession creates an unauthenticated Reddit session
 func NewSession(ua string) *Session {
 	return &Session{
 		client:    &http.Client{},
+		sem:       make(chan struct{}, DefaultConcurrency),
 		useragent: ua,
 	}
 }
 
-// Me returns Account type populated with data for the currently
-// authenticated user.  This is equivalent to using Session.User()
-// and providing the authenticated username.
-func (s *Session) Me() (*Account, error) {
-	resp, err := s.get(buildURL(apiMe, true), nil)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// SetClient sets the HTTP client used for all requests of the session, e.g.
+// one with a custom transport or one connected to a redditest.Server.
+func (s *Session) SetClient(c *http.Client) {
+	s.client = c
+}
 
-	thing := Thing{}
-	err = json.NewDecoder(resp.Body).Decode(&thing)
-	if err != nil {
-		return nil, err
+// SetConcurrency sets the max number of requests in flight, at least 1.
+// Further requests wait for one to finish. Requests in flight count
+// against the previous limit until they finish.
+func (s *Session) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
 	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sem = make(chan struct{}, n)
+}
 
-	if thing.Kind != TypeAccount {
-		// TODO.. handle error
-	}
+// Cookie returns the session cookie, empty if not logged in
+func (s *Session) Cookie() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.cookie
+}
+
+// RateLimit returns a snapshot of the rate limit usage, updated on each
+// API request
+func (s *Session) RateLimit() RateLimit {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.rateLimit
+}
 
+// authenticated reports whether the session has a session cookie
+func (s *Session) authenticated() bool {
+	return len(s.Cookie()) > 0
+}
+
+// setCredentials sets the session cookie and modhash
+func (s *Session) setCredentials(cookie string, modhash string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.cookie = cookie
+	s.modhash = modhash
+}
+
+// Me returns Account type populated with data for the currently
+// authenticated user.  This is equivalent to using Session.User()
+// and providing the authenticated username.
+func (s *Session) Me() (*Account, error) {
 	account := Account{}
-	err = json.Unmarshal(thing.Data, &account)
+	err := s.getThing(s.url(apiMe), nil, TypeAccount, &account)
 	if err != nil {
 		return nil, err
 	}
-
 	return &account, nil
 }
 
 // User returns Account type populated with data for user u.
 func (s *Session) User(u string) (*Account, error) {
-	url := fmt.Sprintf(buildURL(apiUserAbout, true), u)
-	resp, err := s.get(url, nil)
+	account := Account{}
+	err := s.getThing(s.url(fmt.Sprintf(apiUserAbout, u)), nil, TypeAccount, &account)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return &account, nil
+}
 
-	thing := Thing{}
-	err = json.NewDecoder(resp.Body).Decode(&thing)
+// Subreddit returns Subreddit type populated with data for subreddit sub
+func (s *Session) Subreddit(sub string) (*Subreddit, error) {
+	subreddit := Subreddit{}
+	err := s.getThing(s.url(fmt.Sprintf(apiSubredditAbout, sub)), nil, TypeSubreddit, &subreddit)
 	if err != nil {
 		return nil, err
 	}
+	return &subreddit, nil
+}
 
-	if thing.Kind != TypeAccount {
-		// TODO.. handle error
+// Info returns a Listing of the links, comments and subreddits identified
+// by fullnames ids. Unknown or inaccessible items are left out.
+func (s *Session) Info(ids ...Fullname) (*Listing, error) {
+	var names []string
+	for _, id := range ids {
+		if err := id.Validate(); err != nil {
+			return nil, err
+		}
+		names = append(names, string(id))
 	}
+	v := url.Values{}
+	v.Set("id", strings.Join(names, ","))
 
-	account := Account{}
-	err = json.Unmarshal(thing.Data, &account)
+	list := Listing{}
+	err := s.call("GET", s.url(apiInfo), v, &list)
 	if err != nil {
 		return nil, err
 	}
-
-	return &account, nil
+	if list.Kind != TypeListing {
+		return nil, ErrUnexpectedKind
+	}
+	return &list, nil
 }
 
 // Listing returns a paginated Listing wrapped in a Page type
 func (s *Session) Listing(sub string) *Page {
 	p := Page{}
 	p.s = s
-	p.url = fmt.Sprintf(buildURL(apiListing, true), sub)
+	p.url = s.url(fmt.Sprintf(apiListing, sub))
 	return &p
 }
 
 // Comment posts a reply to parent post p using the raw text t.
 // A successfull post will return the new comments fullname id.
-func (s *Session) Comment(p string, t string) (*CommentResult, error) {
+func (s *Session) Comment(p Fullname, t string) (*CommentResult, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
 	v := url.Values{"api_type": {"json"}}
-	v.Set("thing_id", p)
+	v.Set("thing_id", string(p))
 	v.Set("text", t)
 
-	resp, err := s.post(buildURL(apiComment, true), v)
+	resp, err := s.post(s.url(apiComment), v)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New(resp.Status)
+		return nil, newStatusError(resp)
 	}
 
 	r, err := getJSON(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, withResponse(err, resp)
 	}
 
 	container := struct {
@@ -139,8 +212,12 @@ func (s *Session) Comment(p string, t string) (*CommentResult, error) {
 		return nil, err
 	}
 
+	if len(container.Things) == 0 {
+		return nil, ErrEmptyReply
+	}
+
 	cr := CommentResult{}
-	err = json.Unmarshal(container.Things[0].Data, &cr) // FIXME, verify Things[] is not empty
+	err = json.Unmarshal(container.Things[0].Data, &cr)
 
 	if err != nil {
 		return nil, err
@@ -151,7 +228,7 @@ func (s *Session) Comment(p string, t string) (*CommentResult, error) {
 
 // SetCookie authenticates the current session using a pre-authenticated cookie
 func (s *Session) SetCookie(c string) error {
-	s.Cookie = c
+	s.setCredentials(c, "")
 	acct, err := s.Me()
 	if err != nil {
 		return err
@@ -160,15 +237,14 @@ func (s *Session) SetCookie(c string) error {
 		return ErrBadCookie
 	}
 
-	s.modhash = acct.Modhash
+	s.setCredentials(c, acct.Modhash)
 	return nil
 }
 
 // Login authenticates the current session using username and password
 func (s *Session) Login(u string, p string) error {
 	// Clear cookie and modhash before sending request
-	s.Cookie = ""
-	s.modhash = ""
+	s.setCredentials("", "")
 
 	return s.authenticate(u, p)
 }
@@ -178,19 +254,19 @@ func (s *Session) authenticate(u string, p string) error {
 	v.Set("user", u)
 	v.Set("passwd", p)
 
-	resp, err := s.post(buildURL(apiLogin, true), v)
+	resp, err := s.post(s.url(apiLogin), v)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return errors.New(resp.Status)
+		return newStatusError(resp)
 	}
 
 	r, err := getJSON(resp.Body)
 	if err != nil {
-		return err
+		return withResponse(err, resp)
 	}
 
 	reply := struct {
@@ -211,17 +287,18 @@ func (s *Session) authenticate(u string, p string) error {
 			}
 		}
 	*/
-	s.Cookie = fmt.Sprintf("%s=%s", strCookie, reply.Cookie)
-	s.modhash = reply.Modhash
+	s.setCredentials(fmt.Sprintf("%s=%s", strCookie, reply.Cookie), reply.Modhash)
 
 	return nil
 }
 
 func (s *Session) httpHeaders() http.Header {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
 	h := http.Header{}
 	h.Set("User-Agent", s.useragent)
-	if len(s.Cookie) != 0 {
-		h.Set("Cookie", s.Cookie)
+	if len(s.cookie) != 0 {
+		h.Set("Cookie", s.cookie)
 	}
 	if len(s.modhash) != 0 {
 		h.Set("X-Modhash", s.modhash)
@@ -234,37 +311,143 @@ func (s *Session) get(u string, v url.Values) (*http.Response, error) {
 	if v != nil {
 		values = fmt.Sprintf("?%s", v.Encode())
 	}
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s%s", u, values), nil)
+	req, err := s.newRequest("GET", fmt.Sprintf("%s%s", u, values), nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header = s.httpHeaders()
-	return s.client.Do(req)
+	return s.do(req)
 }
 
 func (s *Session) post(u string, v url.Values) (*http.Response, error) {
+	return s.form("POST", u, v)
+}
+
+// form sends v as an url encoded form body using the given HTTP method
+func (s *Session) form(method string, u string, v url.Values) (*http.Response, error) {
 	if v == nil {
 		v = url.Values{}
 	}
-	req, err := http.NewRequest("POST", u, strings.NewReader(v.Encode()))
+	req, err := s.newRequest(method, u, strings.NewReader(v.Encode()))
 	if err != nil {
 		return nil, err
 	}
-	req.Header = s.httpHeaders()
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	return s.do(req)
 }
 
+// call is a convenience method used by API methods returning plain JSON.
+// GET requests send v as query parameters, other methods as a form body.
+// The reply is decoded into out unless out is nil.
+func (s *Session) call(method string, u string, v url.Values, out interface{}) error {
+	var resp *http.Response
+	var err error
+	if method == "GET" {
+		resp, err = s.get(u, v)
+	} else {
+		resp, err = s.form(method, u, v)
+	}
+	if err != nil {
+		return err
+	}
+	return decodeReply(resp, out)
+}
+
+// callJSON sends body JSON encoded using the given HTTP method.
+// The reply is decoded into out unless out is nil.
+func (s *Session) callJSON(method string, u string, body interface{}, out interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := s.newRequest(method, u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	return decodeReply(resp, out)
+}
+
+// getThing fetches a single Thing of the given kind and decodes
+// its data into out.
+func (s *Session) getThing(u string, v url.Values, kind string, out interface{}) error {
+	thing := Thing{}
+	err := s.call("GET", u, v, &thing)
+	if err != nil {
+		return err
+	}
+
+	if thing.Kind != kind {
+		return ErrUnexpectedKind
+	}
+
+	return json.Unmarshal(thing.Data, out)
+}
+
+// api posts v to a Reddit JSON API method, i.e. a method replying
+// with a {"json": {...}} wrapped object, and returns the decoded reply.
+func (s *Session) api(u string, v url.Values) (*jsonAPIReply, error) {
+	if v == nil {
+		v = url.Values{}
+	}
+	v.Set("api_type", "json")
+
+	resp, err := s.post(u, v)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+
+	r, err := getJSON(resp.Body)
+	return r, withResponse(err, resp)
+}
+
+// do sends req using the response cache, if enabled for req
 func (s *Session) do(req *http.Request) (*http.Response, error) {
-	s.lock.Lock()
-	resp, err := s.client.Do(req)
-	s.lock.Unlock()
+	// Replies to authenticated sessions include user specific fields
+	if req.Method == "GET" && s.cache != nil && !s.authenticated() && req.URL.Path != apiMe {
+		return s.cached(req)
+	}
+	return s.send(req)
+}
+
+// send sends req through the middleware, once the number of requests in
+// flight permits, and updates the rate limit
+func (s *Session) send(req *http.Request) (*http.Response, error) {
+	// Release the slot taken even if SetConcurrency replaces the semaphore
+	s.lock.RLock()
+	sem := s.sem
+	s.lock.RUnlock()
+	if sem != nil {
+		select {
+		case sem <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		defer func() { <-sem }()
+	}
+	if s.instrumentation != nil {
+		req = withAttempts(req)
+	}
+	resp, err := s.doer().Do(req)
 	if err == nil {
+		rl := RateLimit{}
 		// Atoi errors can safely be ignored as 0 will be returned on bad input
 		// and we will not need to check that each header really exists
-		s.RateLimit.Used, _ = strconv.Atoi(resp.Header.Get("X-Ratelimit-Used"))
-		s.RateLimit.Reset, _ = strconv.Atoi(resp.Header.Get("X-Ratelimit-Reset"))
-		s.RateLimit.Remaining, _ = strconv.Atoi(resp.Header.Get("X-Ratelimit-Remaining"))
+		rl.Used, _ = strconv.Atoi(resp.Header.Get("X-Ratelimit-Used"))
+		rl.Reset, _ = strconv.Atoi(resp.Header.Get("X-Ratelimit-Reset"))
+		rl.Remaining, _ = strconv.Atoi(resp.Header.Get("X-Ratelimit-Remaining"))
+		s.lock.Lock()
+		s.rateLimit = rl
+		s.lock.Unlock()
 	}
 	return resp, err
 }
@@ -0,0 +1,141 @@
+package rego
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rewriteTransport sends all requests to a local test server
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// newTestSession returns a session with all requests served by h
+func newTestSession(h http.Handler) (*Session, *httptest.Server) {
+	ts := httptest.NewServer(h)
+	target, _ := url.Parse(ts.URL)
+	s := NewSession("RegoTest/1.0")
+	s.SetClient(&http.Client{Transport: rewriteTransport{target}})
+	return s, ts
+}
+
+func Test_Concurrent(t *testing.T) {
+	var lock sync.Mutex
+	inflight, peak := 0, 0
+	release := make(chan struct{})
+	once := sync.Once{}
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		inflight++
+		if inflight > peak {
+			peak = inflight
+		}
+		if inflight == 2 {
+			once.Do(func() { close(release) })
+		}
+		lock.Unlock()
+		// Hold the first request until a second one is in flight
+		select {
+		case <-release:
+		case <-time.After(time.Second):
+		}
+
+		w.Header().Set("X-Ratelimit-Remaining", "10")
+		switch r.URL.Path {
+		case apiLogin:
+			fmt.Fprint(w, `{"json": {"errors": [], "data": {"cookie": "x", "modhash": "y"}}}`)
+		case apiComment:
+			fmt.Fprint(w, `{"json": {"errors": [], "data": {"things": [{"kind": "t1", "data": {"id": "t1_c"}}]}}}`)
+		default:
+			fmt.Fprint(w, `{"kind": "t2", "data": {"name": "bob", "modhash": "y"}}`)
+		}
+		lock.Lock()
+		inflight--
+		lock.Unlock()
+	}))
+	defer ts.Close()
+	s.SetConcurrency(2)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			switch i % 4 {
+			case 0:
+				err = s.Login("bob", "pw")
+			case 1:
+				_, err = s.Comment("t3_x", "Hi")
+			case 2:
+				_, err = s.User("bob")
+			case 3:
+				err = s.SetCookie("reddit_session=x")
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			s.RateLimit()
+			s.Cookie()
+		}(i)
+	}
+	wg.Wait()
+
+	if peak != 2 {
+		t.Errorf("Got %d requests in flight, wanted 2", peak)
+	}
+	if rl := s.RateLimit(); rl.Remaining != 10 {
+		t.Errorf("Got %+v, wanted the rate limit updated", rl)
+	}
+}
+
+func Test_SetConcurrency(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		fmt.Fprint(w, `{"kind": "t2", "data": {"name": "bob"}}`)
+	}))
+	defer ts.Close()
+	s.SetConcurrency(1)
+
+	done := make(chan error, 2)
+	get := func() {
+		_, err := s.User("bob")
+		done <- err
+	}
+	go get()
+	<-started
+	// The request in flight releases the previous semaphore
+	s.SetConcurrency(1)
+	go get()
+	<-started
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Request stuck releasing its slot")
+		}
+	}
+	if n := len(s.sem); n != 0 {
+		t.Errorf("Got %d slots taken, wanted 0", n)
+	}
+}
@@ -0,0 +1,73 @@
+package rego
+
+import (
+	"io"
+	"net/http"
+	"path"
+)
+
+// TLSPolicy selects which requests of a session use TLS
+type TLSPolicy int
+
+// TLS policies. Authenticated sessions and logins always use TLS as the
+// session cookie and password must not be sent in plain text.
+const (
+	TLSAlways        TLSPolicy = iota // Use TLS for all requests
+	TLSAuthenticated                  // Use TLS only for authenticated sessions
+)
+
+// endpointTLS overrides the TLS policy of endpoints matching a path pattern
+type endpointTLS struct {
+	pattern string
+	tls     bool
+}
+
+// SetTLSPolicy sets the TLS policy of the session, TLSAlways by default.
+//
+// Unauthenticated sessions using TLSAuthenticated send requests using plain
+// HTTP, which may be served by Reddit caches and do not count against the
+// rate limit.
+func (s *Session) SetTLSPolicy(p TLSPolicy) {
+	s.tlsPolicy = p
+}
+
+// SetEndpointTLS overrides the TLS policy of unauthenticated requests to
+// endpoints with a path matching pattern, e.g. "/r/*/about.json". Patterns
+// use the syntax of path.Match and are tried in the order set.
+func (s *Session) SetEndpointTLS(pattern string, tls bool) {
+	s.endpointTLS = append(s.endpointTLS, endpointTLS{pattern, tls})
+}
+
+// url returns the URL of API endpoint path p using the scheme selected by
+// the TLS policy
+func (s *Session) url(p string) string {
+	return buildURL(p, s.secure(p))
+}
+
+// secure reports whether requests to endpoint path p use TLS
+func (s *Session) secure(p string) bool {
+	if s.authenticated() || p == apiLogin {
+		return true
+	}
+	for _, e := range s.endpointTLS {
+		if ok, _ := path.Match(e.pattern, p); ok {
+			return e.tls
+		}
+	}
+	return s.tlsPolicy == TLSAlways
+}
+
+// newRequest returns a request with the session headers. Plain HTTP URLs,
+// e.g. of a Page created before logging in, are upgraded to TLS for
+// authenticated sessions.
+func (s *Session) newRequest(method string, u string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if s.authenticated() && req.URL.Scheme == "http" {
+		req.URL.Scheme = "https"
+	}
+	req.Header = s.httpHeaders()
+	return req, nil
+}
@@ -0,0 +1,109 @@
+package rego
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func Test_secure(t *testing.T) {
+	var tests = []struct {
+		policy TLSPolicy
+		cookie string
+		path   string
+		secure bool
+	}{
+		{TLSAlways, "", "/r/golang/about.json", true},
+		{TLSAlways, "", "/user/bob/about.json", false}, // Endpoint override
+		{TLSAuthenticated, "", "/r/golang/new.json", false},
+		{TLSAuthenticated, "", "/r/golang/about.json", true}, // Endpoint override
+		{TLSAuthenticated, "", apiLogin, true},
+		{TLSAuthenticated, "reddit_session=x", "/r/golang/new.json", true},
+		{TLSAlways, "reddit_session=x", "/user/bob/about.json", true},
+	}
+
+	for i, test := range tests {
+		s := NewSession("RegoTest/1.0")
+		s.SetTLSPolicy(test.policy)
+		s.SetEndpointTLS("/user/*/about.json", false)
+		s.SetEndpointTLS("/r/*/about.json", true)
+		s.cookie = test.cookie
+		if secure := s.secure(test.path); secure != test.secure {
+			t.Errorf("%d: Got %t for %s, wanted %t", i, secure, test.path, test.secure)
+		}
+	}
+}
+
+// schemeTransport records the scheme of each request path
+type schemeTransport struct {
+	rewriteTransport
+	schemes map[string]string
+}
+
+func (t schemeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.schemes[req.URL.Path] = req.URL.Scheme
+	return t.rewriteTransport.RoundTrip(req)
+}
+
+func Test_TLSPolicy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case apiLogin:
+			fmt.Fprint(w, `{"json": {"errors": [], "data": {"cookie": "x", "modhash": "y"}}}`)
+		case "/r/golang/new.json":
+			fmt.Fprint(w, `{"kind": "Listing", "data": {"children": []}}`)
+		case apiComment:
+			fmt.Fprint(w, `{"json": {"errors": [], "data": {"things": [{"kind": "t1", "data": {"id": "t1_c"}}]}}}`)
+		default:
+			fmt.Fprint(w, `{"kind": "t2", "data": {"name": "bob"}}`)
+		}
+	}))
+	defer ts.Close()
+	target, _ := url.Parse(ts.URL)
+	tr := schemeTransport{rewriteTransport{target}, map[string]string{}}
+	s := NewSession("RegoTest/1.0")
+	s.SetClient(&http.Client{Transport: tr})
+	s.SetTLSPolicy(TLSAuthenticated)
+
+	page := s.Listing("r/golang/new")
+	_, err := page.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.User("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.schemes["/r/golang/new.json"] != "http" || tr.schemes["/user/bob/about.json"] != "http" {
+		t.Errorf("Got %v, wanted unauthenticated reads using http", tr.schemes)
+	}
+
+	err = s.Login("bob", "pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.schemes[apiLogin] != "https" {
+		t.Errorf("Got %s, wanted login using https", tr.schemes[apiLogin])
+	}
+
+	// The page was created before logging in
+	_, err = page.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Comment("t3_x", "Hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Me()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"/r/golang/new.json", apiComment, apiMe} {
+		if tr.schemes[p] != "https" {
+			t.Errorf("Got %s for %s, wanted authenticated requests using https", tr.schemes[p], p)
+		}
+	}
+}
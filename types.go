@@ -13,118 +13,482 @@ const (
 	TypeAward     = "t6"
 	TypePromo     = "t8" // Promo campain
 	TypeListing   = "Listing"
+	TypeModAction = "modaction"
+	TypeMore      = "more"
+	TypeMulti     = "LabeledMulti"
+
+	TypeKarmaList  = "KarmaList"
+	TypeLiveThread = "LiveUpdateEvent"
+	TypeLiveUpdate = "LiveUpdate"
+	TypeTrophyList = "TrophyList"
+	TypeUserList   = "UserList"
+
+	TypeWikiPage         = "wikipage"
+	TypeWikiPageListing  = "wikipagelisting"
+	TypeWikiPageSettings = "wikipagesettings"
 )
 
 // Thing endpoint represents the Reddit thing base class.
 // https://github.com/reddit/reddit/wiki/JSON#thing-reddit-base-class
 type Thing struct {
-	Data json.RawMessage `json:"data"` // A data structure formatted based on kind
-	ID   string          `json:"id"`   // Item identifier, e.g. "c3v7f8u"
-	Kind string          `json:"kind"` // Kind denotes the item's type.
-	Name string          `json:"name"` // Fullname of item, e.g. "t1_c3v7f8u"
+	Data  json.RawMessage `json:"data"`           // A data structure formatted based on kind
+	ID    string          `json:"id,omitempty"`   // Item identifier, e.g. "c3v7f8u"
+	Kind  string          `json:"kind"`           // Kind denotes the item's type.
+	Name  string          `json:"name,omitempty"` // Fullname of item, e.g. "t1_c3v7f8u"
+	Value interface{}     `json:"-"`              // Decoded data structure, set by Listing.Decode
+}
+
+// MarshalJSON encodes the Thing, children decoded without a kind/data
+// envelope are encoded verbatim
+func (t Thing) MarshalJSON() ([]byte, error) {
+	if len(t.Kind) == 0 && t.Data != nil {
+		return t.Data, nil
+	}
+	type thing Thing
+	return json.Marshal(thing(t))
+}
+
+// UnmarshalJSON decodes a Thing. Listing children not wrapped in a
+// kind/data envelope, e.g. wiki revisions, are kept verbatim in Data.
+func (t *Thing) UnmarshalJSON(b []byte) error {
+	type thing Thing
+	err := json.Unmarshal(b, (*thing)(t))
+	if err != nil {
+		return err
+	}
+	if t.Data == nil {
+		t.Data = append(json.RawMessage{}, b...)
+	}
+	return nil
+}
+
+// Timestamp is a point in time sent by Reddit as fractional epoch seconds,
+// e.g. 1400000000.5. A null or missing timestamp decodes as the zero time.
+type Timestamp struct {
+	time.Time
+}
+
+// MarshalJSON encodes the timestamp as exact epoch seconds, or null if zero
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(formatEpoch(t.Time)), nil
+}
+
+// UnmarshalJSON decodes epoch seconds, fractional seconds are kept exact
+// down to the nanosecond
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	var n *json.Number
+	err := json.Unmarshal(b, &n)
+	if err != nil {
+		return err
+	}
+
+	t.Time = time.Time{}
+	if n == nil {
+		return nil
+	}
+	t.Time, err = parseEpoch(n.String())
+	return err
 }
 
 // Created implements the Created class.
 type Created struct {
-	Local json.Number `json:"created"`     // Time of creation in local epoch-second format
-	UTC   json.Number `json:"created_utc"` // Time of creation in UTC epoch-second format
+	Local Timestamp `json:"created"`     // Time of creation, offset by the server time zone
+	UTC   Timestamp `json:"created_utc"` // Time of creation
 }
 
-// Time returns created time in local format
+// Time returns the time of creation, falling back to the local
+// timestamp if no UTC timestamp was sent
 func (c *Created) Time() time.Time {
-	return timeFromNumber(c.UTC)
+	if c.UTC.IsZero() {
+		return c.Local.Time
+	}
+	return c.UTC.Time
+}
+
+// Age returns the time elapsed since creation
+func (c *Created) Age() time.Duration {
+	return time.Since(c.Time())
+}
+
+// VoteState is the vote of the logged-in user on a Thing
+type VoteState int
+
+// Vote states, the values match the vote direction used by the Reddit API
+const (
+	VoteDown VoteState = -1 // Downvoted, sent by Reddit as false
+	VoteNone VoteState = 0  // Not voted, sent by Reddit as null
+	VoteUp   VoteState = 1  // Upvoted, sent by Reddit as true
+)
+
+// String returns "up", "down" or "none"
+func (v VoteState) String() string {
+	switch v {
+	case VoteUp:
+		return "up"
+	case VoteDown:
+		return "down"
+	}
+	return "none"
+}
+
+// MarshalJSON encodes the vote state the way Reddit does, as true, false or null
+func (v VoteState) MarshalJSON() ([]byte, error) {
+	switch v {
+	case VoteUp:
+		return []byte("true"), nil
+	case VoteDown:
+		return []byte("false"), nil
+	}
+	return []byte("null"), nil
+}
+
+// UnmarshalJSON decodes a Reddit likes value of true, false or null
+func (v *VoteState) UnmarshalJSON(b []byte) error {
+	var likes *bool
+	err := json.Unmarshal(b, &likes)
+	if err != nil {
+		return err
+	}
+
+	*v = VoteNone
+	if likes != nil {
+		*v = VoteDown
+		if *likes {
+			*v = VoteUp
+		}
+	}
+	return nil
 }
 
 // Votable implements the Votable class
 type Votable struct {
-	Downs int  `json:"downs"`           // Number of downvotes. (includes own)
-	Likes bool `json:"likes,omitempty"` // True if thing is liked by the user
-	Ups   int  `json:"ups"`             // Number of upvotes. (includes own)
+	Downs int       `json:"downs"` // Number of downvotes. (includes own)
+	Likes VoteState `json:"likes"` // Vote of the logged-in user
+	Ups   int       `json:"ups"`   // Number of upvotes. (includes own)
 }
 
 // Account represents a Reddit user account
 type Account struct {
-	CommentKarma  int    `json:"comment_karma"` // User's comment karma
-	GoldCredits   int    `json:"gold_creddits,omitempty"`
-	HasMail       bool   `json:"has_mail"`     // User has unread mail?
-	HasModMail    bool   `json:"has_mod_mail"` // User has unread mod mail?
-	HideRobots    bool   `json:"hide_from_robots,omitempty"`
-	ID            string `json:"id"`                 // ID of the account; prepend t2_ to get fullname
-	IsFriend      bool   `json:"is_friend"`          // Logged-in user has this user set as a friend
-	IsGold        bool   `json:"is_gold"`            // Reddit gold status
-	IsMod         bool   `json:"is_mod"`             // This account moderates a subreddits
-	LinkKarma     int    `json:"link_karma"`         // User's link karma
-	Modhash       string `json:"modhash"`            // Current modhash, not present if not your account
-	Name          string `json:"name"`               // The username of the account
-	Over18        bool   `json:"over_18"`            // If this account is set to be over 18
-	VerifiedEmail bool   `json:"has_verified_email"` // User has a verified email address
+	CommentKarma  int                        `json:"comment_karma"` // User's comment karma
+	Extra         map[string]json.RawMessage `json:"-"`             // Fields not decoded into the struct, kept when encoding
+	GoldCredits   int                        `json:"gold_creddits,omitempty"`
+	HasMail       bool                       `json:"has_mail"`     // User has unread mail?
+	HasModMail    bool                       `json:"has_mod_mail"` // User has unread mod mail?
+	HideRobots    bool                       `json:"hide_from_robots,omitempty"`
+	ID            string                     `json:"id"`                 // ID of the account; prepend t2_ to get fullname
+	IsFriend      bool                       `json:"is_friend"`          // Logged-in user has this user set as a friend
+	IsGold        bool                       `json:"is_gold"`            // Reddit gold status
+	IsMod         bool                       `json:"is_mod"`             // This account moderates a subreddits
+	LinkKarma     int                        `json:"link_karma"`         // User's link karma
+	Modhash       string                     `json:"modhash"`            // Current modhash, not present if not your account
+	Name          string                     `json:"name"`               // The username of the account
+	Over18        bool                       `json:"over_18"`            // If this account is set to be over 18
+	VerifiedEmail bool                       `json:"has_verified_email"` // User has a verified email address
 	Created
 }
 
+// UnmarshalJSON decodes a t2 Thing data structure, unknown fields are kept in Extra
+func (a *Account) UnmarshalJSON(b []byte) error {
+	type account Account
+	extra, err := unmarshalExtra(b, (*account)(a))
+	if err != nil {
+		return err
+	}
+	a.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the account as sent by Reddit, including Extra fields
+func (a Account) MarshalJSON() ([]byte, error) {
+	type account Account
+	return marshalExtra((*account)(&a), a.Extra)
+}
+
 // Link represents a subreddit post link
 type Link struct {
-	AuthorFlairClass string          `json:"author_flair_css_class"` // CSS class of the author's flair
-	AuthorFlairText  string          `json:"author_flair_text"`      // Text of the author's flair
-	Author           string          `json:"author"`                 // Account name of the poster
-	Clicked          bool            `json:"clicked"`                //
-	Distinguished    string          `json:"distinguished"`          //
-	Domain           string          `json:"domain"`                 // The domain of this link
-	Edited           Edited          `json:"edited"`                 //
-	Hidden           bool            `json:"hidden"`                 // True if the post is hidden by user
-	ID               string          `json:"id"`                     // Item identifier, e.g. "c3v7f8u"
-	IsNsfw           bool            `json:"over_18"`                // True if the post is tagged as NSFW
-	Likes            bool            `json:"likes"`                  // How the logged-in user has voted on the link
-	LinkFlairClass   string          `json:"link_flair_css_class"`   //
-	LinkFlairText    string          `json:"link_flair_text"`        //
-	MediaEmbed       json.RawMessage `json:"media_embed"`            //
-	Media            json.RawMessage `json:"media"`                  //
-	Name             string          `json:"name"`                   // Fullname of item, e.g. "t3_c3v7f8u"
-	NumComments      int             `json:"num_comments"`           //
-	Permalink        string          `json:"permalink"`              // Relative URL of the permanent link for this link
-	Saved            bool            `json:"saved"`                  // True if this post is saved by the logged in user
-	Score            int             `json:"score"`                  // The net-score of the link
-	Selfpost         bool            `json:"is_self"`                // True if this link is a selfpost
-	SelftextHTML     string          `json:"selftext_html"`          //
-	Selftext         string          `json:"selftext"`               //
-	Stickied         bool            `json:"stickied"`               //
-	SubredditID      string          `json:"subreddit_id"`           //
-	Subreddit        string          `json:"subreddit"`              //
-	Thumbnail        string          `json:"thumbnail"`              //
-	Title            string          `json:"title"`                  //
-	URL              string          `json:"url"`                    //
-	Visited          bool            `json:"visited"`                //
+	ApprovedBy       *string                    `json:"approved_by"`            // Who approved this link, nil if not a mod
+	AuthorFlairClass string                     `json:"author_flair_css_class"` // CSS class of the author's flair
+	AuthorFlairText  string                     `json:"author_flair_text"`      // Text of the author's flair
+	Author           string                     `json:"author"`                 // Account name of the poster
+	BannedBy         *string                    `json:"banned_by"`              // Who removed this link, nil if not a mod
+	Clicked          bool                       `json:"clicked"`                //
+	CrosspostParent  string                     `json:"crosspost_parent"`       // Fullname of the crossposted link
+	CrosspostParents []Link                     `json:"crosspost_parent_list"`  // The crossposted link, if a crosspost
+	Distinguished    string                     `json:"distinguished"`          //
+	Domain           string                     `json:"domain"`                 // The domain of this link
+	Edited           Edited                     `json:"edited"`                 //
+	Extra            map[string]json.RawMessage `json:"-"`                      // Fields not decoded into the struct, kept when encoding
+	GalleryData      *GalleryData               `json:"gallery_data"`           // Gallery images in display order, nil unless a gallery
+	Hidden           bool                       `json:"hidden"`                 // True if the post is hidden by user
+	ID               string                     `json:"id"`                     // Item identifier, e.g. "c3v7f8u"
+	IsGallery        bool                       `json:"is_gallery"`             // True if the link is an image gallery
+	IsNsfw           bool                       `json:"over_18"`                // True if the post is tagged as NSFW
+	IsVideo          bool                       `json:"is_video"`               // True if the link is a Reddit hosted video
+	LinkFlairClass   string                     `json:"link_flair_css_class"`   //
+	LinkFlairText    string                     `json:"link_flair_text"`        //
+	MediaEmbed       MediaEmbed                 `json:"media_embed"`            //
+	MediaMetadata    map[string]MediaMetadata   `json:"media_metadata"`         // Gallery and inline media keyed by media ID
+	Media            *Media                     `json:"media"`                  // Video or embedded media, nil if none
+	Name             string                     `json:"name"`                   // Fullname of item, e.g. "t3_c3v7f8u"
+	NumComments      int                        `json:"num_comments"`           //
+	NumReports       *int                       `json:"num_reports"`            // Number of times the link has been reported, nil if not a mod
+	Permalink        string                     `json:"permalink"`              // Relative URL of the permanent link for this link
+	PollData         *PollData                  `json:"poll_data"`              // Poll of a poll link, nil otherwise
+	PostHint         string                     `json:"post_hint"`              // e.g. "image", "hosted:video" or "link"
+	Preview          *Preview                   `json:"preview"`                // Preview images, nil if none
+	Saved            bool                       `json:"saved"`                  // True if this post is saved by the logged in user
+	Score            int                        `json:"score"`                  // The net-score of the link
+	SecureMediaEmbed MediaEmbed                 `json:"secure_media_embed"`     //
+	SecureMedia      *Media                     `json:"secure_media"`           // Media served over HTTPS, nil if none
+	Selfpost         bool                       `json:"is_self"`                // True if this link is a selfpost
+	SelftextHTML     string                     `json:"selftext_html"`          //
+	Selftext         string                     `json:"selftext"`               //
+	Stickied         bool                       `json:"stickied"`               //
+	SubredditID      string                     `json:"subreddit_id"`           //
+	Subreddit        string                     `json:"subreddit"`              //
+	Thumbnail        string                     `json:"thumbnail"`              //
+	Title            string                     `json:"title"`                  //
+	URL              string                     `json:"url"`                    //
+	Visited          bool                       `json:"visited"`                //
 	Created
 	Votable
 }
 
+// UnmarshalJSON decodes a t3 Thing data structure, unknown fields are kept in Extra
+func (l *Link) UnmarshalJSON(b []byte) error {
+	type link Link
+	extra, err := unmarshalExtra(b, (*link)(l))
+	if err != nil {
+		return err
+	}
+	l.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the link as sent by Reddit, including Extra fields
+func (l Link) MarshalJSON() ([]byte, error) {
+	type link Link
+	return marshalExtra((*link)(&l), l.Extra)
+}
+
 // Comment represents a subreddit post comment
 type Comment struct {
-	ApprovedBy       string          `json:"approved_by"`            // Who approved this comment, null if not a mod
-	AuthorFlairClass string          `json:"author_flair_css_class"` // CSS class of the author's flair
-	AuthorFlairText  string          `json:"author_flair_text"`      // Text of the author's flair
-	Author           string          `json:"author"`                 // Account name of the poster
-	BannedBy         string          `json:"banned_by"`              // Who removed this comment, null if not a mod
-	BodyHTML         string          `json:"body_html"`              // Formatted HTML text as displayed on Reddit
-	Body             string          `json:"body"`                   // Raw unformatted text of the comment
-	Distinguished    string          `json:"distinguished"`          //
-	Edited           json.RawMessage `json:"edited"`                 //
-	Likes            bool            `json:"likes"`                  // How the logged-in user has voted on the link
-	LinkAuthor       string          `json:"link_author"`            // Author of the parent link
-	LinkID           string          `json:"id"`                     // ID of the link this comment is in
-	LinkTitle        string          `json:"link_title"`             // Title of the parent link
-	LinkURL          string          `json:"title_url"`              // Link URL of the parent link
-	Name             string          `json:"name"`                   // Fullname of item, e.g. "t3_c3v7f8u"
-	NumReports       int             `json:"num_reports"`            // Number of times comment has been reported, null if not a mod
-	ParentID         string          `json:"parent_id"`              // ID of the thing this comment is a reply to
-	Saved            bool            `json:"saved"`                  // True if this post is saved by the logged in user
-	ScoreHidden      bool            `json:"score_hidden"`           // Whether the comment's score is currently hidden.
-	Score            int             `json:"score"`                  // The net-score of the link
-	SubredditID      string          `json:"subreddit_id"`           // ID of the subreddit
-	Subreddit        string          `json:"subreddit"`              // Subreddit name
+	ApprovedBy       *string                    `json:"approved_by"`            // Who approved this comment, nil if not a mod
+	AuthorFlairClass string                     `json:"author_flair_css_class"` // CSS class of the author's flair
+	AuthorFlairText  string                     `json:"author_flair_text"`      // Text of the author's flair
+	Author           string                     `json:"author"`                 // Account name of the poster
+	BannedBy         *string                    `json:"banned_by"`              // Who removed this comment, nil if not a mod
+	BodyHTML         string                     `json:"body_html"`              // Formatted HTML text as displayed on Reddit
+	Body             string                     `json:"body"`                   // Raw unformatted text of the comment
+	Distinguished    string                     `json:"distinguished"`          //
+	Edited           Edited                     `json:"edited"`                 //
+	Extra            map[string]json.RawMessage `json:"-"`                      // Fields not decoded into the struct, kept when encoding
+	ID               string                     `json:"id"`                     // Item identifier, e.g. "c3v7f8u"
+	LinkAuthor       string                     `json:"link_author"`            // Author of the parent link
+	LinkID           string                     `json:"link_id"`                // Fullname of the link this comment is in
+	LinkTitle        string                     `json:"link_title"`             // Title of the parent link
+	LinkURL          string                     `json:"link_url"`               // Link URL of the parent link
+	Name             string                     `json:"name"`                   // Fullname of item, e.g. "t1_c3v7f8u"
+	NumReports       *int                       `json:"num_reports"`            // Number of times comment has been reported, nil if not a mod
+	ParentID         string                     `json:"parent_id"`              // Fullname of the thing this comment is a reply to
+	Saved            bool                       `json:"saved"`                  // True if this post is saved by the logged in user
+	ScoreHidden      bool                       `json:"score_hidden"`           // Whether the comment's score is currently hidden.
+	Score            int                        `json:"score"`                  // The net-score of the link
+	SubredditID      string                     `json:"subreddit_id"`           // Fullname of the subreddit
+	Subreddit        string                     `json:"subreddit"`              // Subreddit name
 	Created
 	Votable
 }
 
+// UnmarshalJSON decodes a t1 Thing data structure, unknown fields are kept in Extra
+func (c *Comment) UnmarshalJSON(b []byte) error {
+	type comment Comment
+	extra, err := unmarshalExtra(b, (*comment)(c))
+	if err != nil {
+		return err
+	}
+	c.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the comment as sent by Reddit, including Extra fields
+func (c Comment) MarshalJSON() ([]byte, error) {
+	type comment Comment
+	return marshalExtra((*comment)(&c), c.Extra)
+}
+
+// Message represents a private message or comment reply in the inbox
+type Message struct {
+	Author        string                     `json:"author"`             // Account name of the sender
+	BodyHTML      string                     `json:"body_html"`          // Formatted HTML text as displayed on Reddit
+	Body          string                     `json:"body"`               // Raw unformatted text of the message
+	Context       string                     `json:"context"`            // Relative URL of a comment reply with context
+	Dest          string                     `json:"dest"`               // Account name of the recipient
+	Distinguished string                     `json:"distinguished"`      //
+	Extra         map[string]json.RawMessage `json:"-"`                  // Fields not decoded into the struct, kept when encoding
+	FirstMessage  string                     `json:"first_message_name"` // Fullname of the first message in the thread
+	ID            string                     `json:"id"`                 // Item identifier, e.g. "c3v7f8u"
+	LinkTitle     string                     `json:"link_title"`         // Title of the link of a comment reply
+	Name          string                     `json:"name"`               // Fullname of item, e.g. "t4_c3v7f8u"
+	New           bool                       `json:"new"`                // True if the message is unread
+	ParentID      string                     `json:"parent_id"`          // Fullname of the message or comment replied to
+	Replies       json.RawMessage            `json:"replies"`            //
+	Subject       string                     `json:"subject"`            //
+	Subreddit     string                     `json:"subreddit"`          // Subreddit of a comment reply or modmail
+	WasComment    bool                       `json:"was_comment"`        // True if the message is a comment reply
+	Created
+}
+
+// UnmarshalJSON decodes a t4 Thing data structure, unknown fields are kept in Extra
+func (m *Message) UnmarshalJSON(b []byte) error {
+	type message Message
+	extra, err := unmarshalExtra(b, (*message)(m))
+	if err != nil {
+		return err
+	}
+	m.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the message as sent by Reddit, including Extra fields
+func (m Message) MarshalJSON() ([]byte, error) {
+	type message Message
+	return marshalExtra((*message)(&m), m.Extra)
+}
+
+// Subreddit represents a subreddit
+type Subreddit struct {
+	AccountsActive      int                        `json:"accounts_active"`       // Number of users active in the last 15 minutes
+	DescriptionHTML     string                     `json:"description_html"`      // Formatted HTML sidebar text
+	Description         string                     `json:"description"`           // Raw markdown sidebar text
+	DisplayNamePrefixed string                     `json:"display_name_prefixed"` // e.g. "r/golang"
+	DisplayName         string                     `json:"display_name"`          // e.g. "golang"
+	Extra               map[string]json.RawMessage `json:"-"`                     // Fields not decoded into the struct, kept when encoding
+	ID                  string                     `json:"id"`                    // Item identifier, e.g. "2rc7j"
+	Name                string                     `json:"name"`                  // Fullname of item, e.g. "t5_2rc7j"
+	Over18              bool                       `json:"over18"`                // True if the subreddit is tagged as NSFW
+	PublicDescription   string                     `json:"public_description"`    //
+	Quarantine          bool                       `json:"quarantine"`            // True if the subreddit is quarantined
+	Subscribers         int                        `json:"subscribers"`           //
+	SubredditType       string                     `json:"subreddit_type"`        // "public", "private", "restricted", ...
+	Title               string                     `json:"title"`                 //
+	URL                 string                     `json:"url"`                   // Relative URL, e.g. "/r/golang/"
+	UserIsBanned        bool                       `json:"user_is_banned"`        // Logged-in user is banned
+	UserIsModerator     bool                       `json:"user_is_moderator"`     // Logged-in user is a moderator
+	UserIsSubscriber    bool                       `json:"user_is_subscriber"`    // Logged-in user is subscribed
+	Created
+}
+
+// UnmarshalJSON decodes a t5 Thing data structure, unknown fields are kept in Extra
+func (s *Subreddit) UnmarshalJSON(b []byte) error {
+	type subreddit Subreddit
+	extra, err := unmarshalExtra(b, (*subreddit)(s))
+	if err != nil {
+		return err
+	}
+	s.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the subreddit as sent by Reddit, including Extra fields
+func (s Subreddit) MarshalJSON() ([]byte, error) {
+	type subreddit Subreddit
+	return marshalExtra((*subreddit)(&s), s.Extra)
+}
+
+// PromoCampaign represents a promoted link campaign
+type PromoCampaign struct {
+	Extra     map[string]json.RawMessage `json:"-"`          // Fields not decoded into the struct, kept when encoding
+	ID        string                     `json:"id"`         // Item identifier
+	LinkID    string                     `json:"link"`       // Fullname of the promoted link
+	Name      string                     `json:"name"`       // Fullname of item, e.g. "t8_c3v7f8u"
+	StartDate string                     `json:"start_date"` //
+	EndDate   string                     `json:"end_date"`   //
+}
+
+// UnmarshalJSON decodes a t8 Thing data structure, unknown fields are kept in Extra
+func (p *PromoCampaign) UnmarshalJSON(b []byte) error {
+	type campaign PromoCampaign
+	extra, err := unmarshalExtra(b, (*campaign)(p))
+	if err != nil {
+		return err
+	}
+	p.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the campaign as sent by Reddit, including Extra fields
+func (p PromoCampaign) MarshalJSON() ([]byte, error) {
+	type campaign PromoCampaign
+	return marshalExtra((*campaign)(&p), p.Extra)
+}
+
+// More represents a placeholder for comments left out of a comment tree
+type More struct {
+	Children []string                   `json:"children"`  // IDs of the comments left out
+	Count    int                        `json:"count"`     // Number of comments left out
+	Depth    int                        `json:"depth"`     // Depth in the comment tree
+	Extra    map[string]json.RawMessage `json:"-"`         // Fields not decoded into the struct, kept when encoding
+	ID       string                     `json:"id"`        //
+	Name     string                     `json:"name"`      // Fullname of item, e.g. "t1_c3v7f8u"
+	ParentID string                     `json:"parent_id"` // Fullname of the parent comment or link
+}
+
+// UnmarshalJSON decodes a more Thing data structure, unknown fields are kept in Extra
+func (m *More) UnmarshalJSON(b []byte) error {
+	type more More
+	extra, err := unmarshalExtra(b, (*more)(m))
+	if err != nil {
+		return err
+	}
+	m.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the placeholder as sent by Reddit, including Extra fields
+func (m More) MarshalJSON() ([]byte, error) {
+	type more More
+	return marshalExtra((*more)(&m), m.Extra)
+}
+
+// ModAction represents an entry in a subreddit moderation log
+type ModAction struct {
+	Action          string                     `json:"action"`           // e.g. "removelink", "banuser"
+	Description     string                     `json:"description"`      //
+	Details         string                     `json:"details"`          //
+	Extra           map[string]json.RawMessage `json:"-"`                // Fields not decoded into the struct, kept when encoding
+	ID              string                     `json:"id"`               // Item identifier, e.g. "ModAction_<uuid>"
+	Mod             string                     `json:"mod"`              // Account name of the moderator
+	SubredditID     string                     `json:"sr_id36"`          //
+	Subreddit       string                     `json:"subreddit"`        // Subreddit name
+	TargetAuthor    string                     `json:"target_author"`    // Account name of the author of the target
+	TargetBody      string                     `json:"target_body"`      //
+	TargetFullname  Fullname                   `json:"target_fullname"`  // Fullname of the target, e.g. "t3_c3v7f8u"
+	TargetPermalink string                     `json:"target_permalink"` //
+	TargetTitle     string                     `json:"target_title"`     //
+	Created
+}
+
+// UnmarshalJSON decodes a modaction Thing data structure, unknown fields are kept in Extra
+func (m *ModAction) UnmarshalJSON(b []byte) error {
+	type action ModAction
+	extra, err := unmarshalExtra(b, (*action)(m))
+	if err != nil {
+		return err
+	}
+	m.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the log entry as sent by Reddit, including Extra fields
+func (m ModAction) MarshalJSON() ([]byte, error) {
+	type action ModAction
+	return marshalExtra((*action)(&m), m.Extra)
+}
+
 // CommentResult is returned when submitting a new comment
 type CommentResult struct {
 	ID          string `json:"id"`          // UNKNOWN
@@ -135,23 +499,33 @@ type CommentResult struct {
 	Parent      string `json:"parent"`      // Parent item
 }
 
+// Edited is the edit state of a link or comment, sent by Reddit as false
+// or the time of the last edit
 type Edited struct {
 	// Post has been edited false/true
 	Status bool
-	// Time last edited in UTC epoch-second format
-	UTC time.Time
+	// Time last edited, zero if unknown
+	UTC Timestamp
 }
 
-func (e *Edited) UnmarshalJSON(b []byte) error {
-	var jnum json.Number
+// MarshalJSON encodes the edit state the way Reddit does
+func (e Edited) MarshalJSON() ([]byte, error) {
+	if !e.Status || e.UTC.IsZero() {
+		return json.Marshal(e.Status)
+	}
+	return e.UTC.MarshalJSON()
+}
 
-	err := json.Unmarshal(b, &jnum)
-	if err != nil {
-		e.UTC = time.Unix(0, 0)
+// UnmarshalJSON decodes false, true, null or the time of the last edit
+func (e *Edited) UnmarshalJSON(b []byte) error {
+	*e = Edited{}
+	if string(b) == "null" {
+		return nil
+	}
+	if string(b) == "true" || string(b) == "false" {
 		return json.Unmarshal(b, &e.Status)
 	}
 
 	e.Status = true
-	e.UTC = timeFromNumber(jnum)
-	return nil
+	return e.UTC.UnmarshalJSON(b)
 }
@@ -1,7 +1,12 @@
 package rego
 
 import (
+	"bytes"
 	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -13,9 +18,11 @@ func Test_Edited(t *testing.T) {
 		status bool
 		date   time.Time
 	}{
-		{"{\"edited\": false }", false, time.Unix(0, 0)},
-		{"{\"edited\": true }", true, time.Unix(0, 0)},
+		{"{\"edited\": false }", false, time.Time{}},
+		{"{\"edited\": true }", true, time.Time{}},
+		{"{\"edited\": null }", false, time.Time{}},
 		{"{\"edited\": 1234567890.0 }", true, time.Unix(1234567890, 0)},
+		{"{\"edited\": 1234567890.25 }", true, time.Unix(1234567890, 250000000)},
 	}
 
 	var data = struct {
@@ -27,8 +34,209 @@ func Test_Edited(t *testing.T) {
 			t.Error(err)
 			continue
 		}
-		if test.status != data.Edited.Status || test.date != data.Edited.UTC {
+		if test.status != data.Edited.Status || !test.date.Equal(data.Edited.UTC.Time) {
 			t.Errorf("Got: %t, %s, Wanted: %t, %s", data.Edited.Status, data.Edited.UTC, test.status, test.date)
 		}
 	}
 }
+
+func Test_VoteState(t *testing.T) {
+	var tests = []struct {
+		json  string
+		state VoteState
+	}{
+		{"true", VoteUp},
+		{"false", VoteDown},
+		{"null", VoteNone},
+	}
+
+	for _, test := range tests {
+		var v Votable
+		err := json.Unmarshal([]byte(`{"likes": `+test.json+`}`), &v)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if v.Likes != test.state {
+			t.Errorf("Got: %s, Wanted: %s", v.Likes, test.state)
+		}
+		b, err := json.Marshal(v.Likes)
+		if err != nil || string(b) != test.json {
+			t.Errorf("Got: %s, Wanted: %s", b, test.json)
+		}
+	}
+}
+
+// decodeTestdata decodes the data structure of the Thing in testdata file name into v
+func decodeTestdata(t *testing.T, name string, v interface{}) {
+	b, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thing := Thing{}
+	err = json.Unmarshal(b, &thing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.Unmarshal(thing.Data, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_DecodeComment(t *testing.T) {
+	c := Comment{}
+	decodeTestdata(t, "comment.json", &c)
+
+	if c.ID != "cabc123" || c.Link() != "t3_xyz" || c.LinkURL != "https://example.com/" {
+		t.Errorf("Got ID %q, link %q, link URL %q", c.ID, c.LinkID, c.LinkURL)
+	}
+	if c.Likes != VoteDown || c.Ups != 3 {
+		t.Errorf("Got likes %s, ups %d", c.Likes, c.Ups)
+	}
+	if !c.Edited.Status || !c.Edited.UTC.Equal(time.Unix(1400000100, 0)) {
+		t.Errorf("Got edited %t, %s", c.Edited.Status, c.Edited.UTC)
+	}
+	if c.ApprovedBy != nil || c.BannedBy != nil || c.NumReports != nil {
+		t.Errorf("Got %v, %v, %v, wanted nil moderation fields", c.ApprovedBy, c.BannedBy, c.NumReports)
+	}
+}
+
+func Test_DecodeLink(t *testing.T) {
+	l := Link{}
+	decodeTestdata(t, "link.json", &l)
+
+	if l.Fullname() != "t3_xyz" || l.Likes != VoteNone || l.Edited.Status {
+		t.Errorf("Got %s, likes %s, edited %t", l.Fullname(), l.Likes, l.Edited.Status)
+	}
+	if l.ApprovedBy == nil || *l.ApprovedBy != "mod" || l.BannedBy != nil {
+		t.Errorf("Got approved by %v, banned by %v", l.ApprovedBy, l.BannedBy)
+	}
+	if l.NumReports == nil || *l.NumReports != 0 {
+		t.Errorf("Got num reports %v, wanted 0", l.NumReports)
+	}
+	if l.Media != nil || l.Preview != nil {
+		t.Errorf("Got media %#v, preview %#v, wanted nil", l.Media, l.Preview)
+	}
+}
+
+func Test_Timestamp(t *testing.T) {
+	var tests = []struct {
+		json string
+		time time.Time
+	}{
+		{"null", time.Time{}},
+		{"1400000000", time.Unix(1400000000, 0)},
+		{"1400000000.123456", time.Unix(1400000000, 123456000)},
+	}
+
+	for _, test := range tests {
+		var ts Timestamp
+		err := json.Unmarshal([]byte(test.json), &ts)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !ts.Equal(test.time) {
+			t.Errorf("Got: %s, Wanted: %s", ts, test.time)
+		}
+		b, err := json.Marshal(ts)
+		if err != nil || string(b) != test.json {
+			t.Errorf("Got: %s, Wanted: %s", b, test.json)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`"soon"`), &Timestamp{}); err == nil {
+		t.Error("Expected error decoding a non-numeric timestamp")
+	}
+}
+
+func Test_Created(t *testing.T) {
+	c := Created{}
+	err := json.Unmarshal([]byte(`{"created": 1400028800.5, "created_utc": 1400000000.5}`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Time().Equal(time.Unix(1400000000, 500000000)) || c.Local.Sub(c.UTC.Time) != 8*time.Hour {
+		t.Errorf("Got UTC %s, local %s", c.UTC, c.Local)
+	}
+	if c.Age() < time.Since(time.Unix(1400000001, 0)) {
+		t.Errorf("Got age %s", c.Age())
+	}
+
+	c = Created{}
+	json.Unmarshal([]byte(`{"created": 1400000000}`), &c)
+	if !c.Time().Equal(time.Unix(1400000000, 0)) {
+		t.Errorf("Got %s, wanted local time fallback", c.Time())
+	}
+}
+
+var update = flag.Bool("update", false, "update golden files in testdata/golden")
+
+func Test_RoundTrip(t *testing.T) {
+	var tests = []struct {
+		file string
+		v    interface{}
+	}{
+		{"account.json", &Account{}},
+		{"comment.json", &Comment{}},
+		{"link.json", &Link{}},
+		{"link_gallery.json", &Link{}},
+		{"link_media.json", &Link{}},
+		{"message.json", &Message{}},
+		{"multi.json", &Multireddit{}},
+		{"subreddit.json", &Subreddit{}},
+		{"wikipage.json", &WikiPage{}},
+	}
+
+	for _, test := range tests {
+		decodeTestdata(t, test.file, test.v)
+		buf := bytes.Buffer{}
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "\t")
+		err := enc.Encode(test.v)
+		if err != nil {
+			t.Errorf("%s: %s", test.file, err)
+			continue
+		}
+		b := buf.Bytes()
+
+		golden := filepath.Join("testdata", "golden", test.file)
+		if *update {
+			err = os.WriteFile(golden, b, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		expected, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != string(expected) {
+			t.Errorf("%s: encoding differs from %s:\n%s", test.file, golden, b)
+		}
+
+		v := reflect.New(reflect.TypeOf(test.v).Elem()).Interface()
+		err = json.Unmarshal(b, v)
+		if err != nil {
+			t.Errorf("%s: %s", test.file, err)
+			continue
+		}
+		if !reflect.DeepEqual(v, test.v) {
+			t.Errorf("%s: got %+v, wanted %+v", test.file, v, test.v)
+		}
+	}
+}
+
+func Test_Extra(t *testing.T) {
+	c := Comment{}
+	decodeTestdata(t, "comment.json", &c)
+
+	if len(c.Extra) != 2 || string(c.Extra["awarders"]) != "[]" || string(c.Extra["controversiality"]) != "0" {
+		t.Errorf("Got extra %q", c.Extra)
+	}
+	if _, ok := c.Extra["link_id"]; ok {
+		t.Error("Known field link_id kept in Extra")
+	}
+}
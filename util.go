@@ -1,14 +1,21 @@
 package rego
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// structKeys caches the JSON keys of struct types, see jsonKeys
+var structKeys sync.Map
+
 // buildURL returns a URI for API-method. If 'secure' is true the scheme will be set to https.
 func buildURL(method string, secure bool) string {
 	scheme := "http"
@@ -31,12 +38,194 @@ func getJSON(rc io.Reader) (*jsonAPIReply, error) {
 	return &r.JSON, newAPIError(&r.JSON)
 }
 
-func timeFromNumber(n json.Number) time.Time {
-	var s, u int
-	parts := strings.Split(n.String(), ".")
-	s, _ = strconv.Atoi(parts[0]) // Seconds
-	if len(parts) > 1 {
-		u, _ = strconv.Atoi(parts[1]) // Microseconds
+// decodeReply decodes a successful JSON reply into out, unless out is nil,
+// and closes the response body
+func decodeReply(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newStatusError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// accountName returns the name of the account wrapped in Thing t
+func accountName(t Thing) string {
+	if t.Kind != TypeAccount {
+		return ""
+	}
+	item := struct {
+		Name string `json:"name"`
+	}{}
+	json.Unmarshal(t.Data, &item)
+	return item.Name
+}
+
+// parseEpoch parses fractional epoch seconds, e.g. "1234.5", without the
+// rounding errors of a float64 conversion. Fractions beyond nanosecond
+// precision are truncated.
+func parseEpoch(s string) (time.Time, error) {
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	sec, frac, _ := strings.Cut(s, ".")
+	neg := strings.HasPrefix(sec, "-")
+	n, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var nsec int64
+	if len(frac) > 0 {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		nsec, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+		if err != nil || nsec < 0 {
+			return time.Time{}, fmt.Errorf("invalid epoch seconds %q", s)
+		}
+		if neg {
+			nsec = -nsec
+		}
+	}
+	return time.Unix(n, nsec), nil
+}
+
+// formatEpoch formats t as epoch seconds, with a fraction only if needed
+func formatEpoch(t time.Time) string {
+	sec, nsec := t.Unix(), t.Nanosecond()
+	if nsec == 0 {
+		return strconv.FormatInt(sec, 10)
+	}
+
+	// The nanosecond part is always positive, e.g. -0.5 is -1 + 0.5
+	sign := ""
+	if sec < 0 {
+		sec, nsec = sec+1, 1e9-nsec
+		if sec == 0 {
+			sign = "-"
+		}
+	}
+	return sign + strconv.FormatInt(sec, 10) + "." + strings.TrimRight(fmt.Sprintf("%09d", nsec), "0")
+}
+
+// jsonKeys returns the lowercased JSON object keys decoded into the fields
+// of struct type t, including those of embedded structs
+func jsonKeys(t reflect.Type) map[string]bool {
+	if keys, ok := structKeys.Load(t); ok {
+		return keys.(map[string]bool)
+	}
+	keys := map[string]bool{}
+	addJSONKeys(keys, t)
+	structKeys.Store(t, keys)
+	return keys
+}
+
+func addJSONKeys(keys map[string]bool, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && len(name) == 0 {
+			addJSONKeys(keys, f.Type)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		keys[strings.ToLower(name)] = true
+	}
+}
+
+// unmarshalExtra decodes b into struct pointer v and returns the fields of
+// b not decoded by v, nil if none
+func unmarshalExtra(b []byte, v interface{}) (map[string]json.RawMessage, error) {
+	err := json.Unmarshal(b, v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(b, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var extra map[string]json.RawMessage
+	keys := jsonKeys(reflect.TypeOf(v))
+	for k, raw := range fields {
+		if keys[strings.ToLower(k)] {
+			continue
+		}
+		if extra == nil {
+			extra = map[string]json.RawMessage{}
+		}
+		// Compacted to encode and compare the same as re-encoded values
+		buf := bytes.Buffer{}
+		if json.Compact(&buf, raw) == nil {
+			raw = buf.Bytes()
+		}
+		extra[k] = raw
+	}
+	return extra, nil
+}
+
+// marshalExtra encodes v adding the fields of extra not encoded by v.
+// Fields are sorted by key when extra is non-empty. HTML characters are
+// left unescaped, as sent by Reddit, unless escaped by the caller's encoder.
+func marshalExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	b, err := encodeJSON(v)
+	if err != nil || len(extra) == 0 {
+		return b, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(b, &fields)
+	if err != nil {
+		return nil, err
+	}
+	for k, raw := range extra {
+		if _, ok := fields[k]; !ok {
+			fields[k] = raw
+		}
+	}
+	return encodeJSON(fields)
+}
+
+// encodeJSON is json.Marshal without escaping of HTML characters
+func encodeJSON(v interface{}) ([]byte, error) {
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// accountThing returns a t2 Thing holding only account name u, as Reddit
+// sends the authors of e.g. wiki revisions. Nil is returned if u is empty.
+func accountThing(u string) *Thing {
+	if len(u) == 0 {
+		return nil
 	}
-	return time.Unix(int64(s), int64(u))
+	data, _ := json.Marshal(map[string]string{"name": u})
+	return &Thing{Data: data, Kind: TypeAccount}
 }
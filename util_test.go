@@ -1,7 +1,6 @@
 package rego
 
 import (
-	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -27,21 +26,47 @@ func Test_buildURL(t *testing.T) {
 	}
 }
 
-func Test_timeFromNumber(t *testing.T) {
+func Test_parseEpoch(t *testing.T) {
 	var tests = []struct {
-		number json.Number
+		number string
 		time   time.Time
+		valid  bool
 	}{
-		{"12345.6789", time.Unix(12345, 6789)},
-		{"12345", time.Unix(12345, 0)},
-		{"123oops456.789", time.Unix(0, 789)},
-		{"123oops456", time.Unix(0, 0)},
+		{"12345.6789", time.Unix(12345, 678900000), true},
+		{"1234.5", time.Unix(1234, 500000000), true},
+		{"1234.000000001", time.Unix(1234, 1), true},
+		{"1234.0000000019", time.Unix(1234, 1), true},
+		{"12345", time.Unix(12345, 0), true},
+		{"1.4e9", time.Unix(1400000000, 0), true},
+		{"-0.5", time.Unix(0, -500000000), true},
+		{"123oops456.789", time.Time{}, false},
+		{"123oops456", time.Time{}, false},
+		{"123.-5", time.Time{}, false},
 	}
 
 	for _, test := range tests {
-		if timeFromNumber(test.number) != test.time {
-			fmt.Printf("%s != %s\n", timeFromNumber(test.number), test.time)
-			t.Fail()
+		tm, err := parseEpoch(test.number)
+		if (err == nil) != test.valid {
+			t.Errorf("Got error %v for %s, wanted valid %t", err, test.number, test.valid)
+			continue
+		}
+		if test.valid && !tm.Equal(test.time) {
+			t.Errorf("Got %s for %s, wanted %s", tm, test.number, test.time)
+		}
+	}
+}
+
+func Test_formatEpoch(t *testing.T) {
+	var tests = []string{"1400000000", "1400000000.5", "1234.000000001", "-1.25", "-0.5"}
+
+	for _, test := range tests {
+		tm, err := parseEpoch(test)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if s := formatEpoch(tm); s != test {
+			t.Errorf("Got: %s, Wanted: %s", s, test)
 		}
 	}
 }
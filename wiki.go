@@ -0,0 +1,277 @@
+package rego
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Wiki page permission levels
+const (
+	WikiPermInherit  = 0 // Use subreddit wiki permissions
+	WikiPermApproved = 1 // Only approved wiki contributors may edit
+	WikiPermMods     = 2 // Only moderators may edit and view
+)
+
+// WikiPage represents a revision of a subreddit wiki page
+type WikiPage struct {
+	ContentHTML    string                     `json:"content_html"`  // Formatted HTML text as displayed on Reddit
+	Content        string                     `json:"content_md"`    // Raw markdown text of the page
+	Extra          map[string]json.RawMessage `json:"-"`             // Fields not decoded into the struct, kept when encoding
+	MayRevise      bool                       `json:"may_revise"`    // True if the logged-in user may edit the page
+	Reason         string                     `json:"reason"`        // Edit reason given for the revision
+	RevisionAuthor string                     `json:"-"`             // Account name of the revision author
+	RevisionDate   Timestamp                  `json:"revision_date"` // Time of the revision
+	RevisionID     string                     `json:"revision_id"`   // Revision identifier
+}
+
+// UnmarshalJSON decodes a wikipage Thing data structure
+func (w *WikiPage) UnmarshalJSON(b []byte) error {
+	type page WikiPage
+	data := struct {
+		*page
+		RevisionBy Thing `json:"revision_by"`
+	}{page: (*page)(w)}
+	extra, err := unmarshalExtra(b, &data)
+	if err != nil {
+		return err
+	}
+
+	w.Extra = extra
+	w.RevisionAuthor = accountName(data.RevisionBy)
+	return nil
+}
+
+// MarshalJSON encodes the wiki page as sent by Reddit, including Extra fields
+func (w WikiPage) MarshalJSON() ([]byte, error) {
+	type page WikiPage
+	return marshalExtra(struct {
+		*page
+		RevisionBy *Thing `json:"revision_by,omitempty"`
+	}{(*page)(&w), accountThing(w.RevisionAuthor)}, w.Extra)
+}
+
+// WikiRevision represents an entry in the revision history of a wiki page
+type WikiRevision struct {
+	Author string                     `json:"-"`               // Account name of the revision author
+	Extra  map[string]json.RawMessage `json:"-"`               // Fields not decoded into the struct, kept when encoding
+	Hidden bool                       `json:"revision_hidden"` // True if the revision is hidden from the history
+	ID     string                     `json:"id"`              // Revision identifier
+	Page   string                     `json:"page"`            // Name of the revised page
+	Reason string                     `json:"reason"`          // Edit reason given for the revision
+	Time   Timestamp                  `json:"timestamp"`       // Time of the revision
+}
+
+// UnmarshalJSON decodes a wiki revision listing item
+func (w *WikiRevision) UnmarshalJSON(b []byte) error {
+	type revision WikiRevision
+	data := struct {
+		*revision
+		Author Thing `json:"author"`
+	}{revision: (*revision)(w)}
+	extra, err := unmarshalExtra(b, &data)
+	if err != nil {
+		return err
+	}
+
+	w.Author = accountName(data.Author)
+	w.Extra = extra
+	return nil
+}
+
+// MarshalJSON encodes the revision as sent by Reddit, including Extra fields
+func (w WikiRevision) MarshalJSON() ([]byte, error) {
+	type revision WikiRevision
+	return marshalExtra(struct {
+		*revision
+		Author *Thing `json:"author,omitempty"`
+	}{(*revision)(&w), accountThing(w.Author)}, w.Extra)
+}
+
+// WikiSettings represents the settings of a wiki page
+type WikiSettings struct {
+	Editors   []string                   `json:"-"`         // Account names of approved editors
+	Extra     map[string]json.RawMessage `json:"-"`         // Fields not decoded into the struct, kept when encoding
+	Listed    bool                       `json:"listed"`    // True if the page is shown in the page list
+	PermLevel int                        `json:"permlevel"` // One of the WikiPerm* levels
+}
+
+// UnmarshalJSON decodes a wikipagesettings Thing data structure, unknown fields are kept in Extra
+func (w *WikiSettings) UnmarshalJSON(b []byte) error {
+	type settings WikiSettings
+	data := struct {
+		*settings
+		Editors []Thing `json:"editors"`
+	}{settings: (*settings)(w)}
+	extra, err := unmarshalExtra(b, &data)
+	if err != nil {
+		return err
+	}
+
+	w.Editors = nil
+	w.Extra = extra
+	for _, e := range data.Editors {
+		w.Editors = append(w.Editors, accountName(e))
+	}
+	return nil
+}
+
+// MarshalJSON encodes the settings as sent by Reddit, including Extra fields
+func (w WikiSettings) MarshalJSON() ([]byte, error) {
+	type settings WikiSettings
+	data := struct {
+		*settings
+		Editors []Thing `json:"editors"`
+	}{settings: (*settings)(&w), Editors: []Thing{}}
+	for _, e := range w.Editors {
+		data.Editors = append(data.Editors, *accountThing(e))
+	}
+	return marshalExtra(data, w.Extra)
+}
+
+// WikiConflictError is returned by Session.EditWikiPage when the page
+// has been revised since the given previous revision.
+type WikiConflictError struct {
+	Diff        string `json:"diffcontent"` // HTML formatted diff between the edit and current page
+	NewContent  string `json:"newcontent"`  // Content of the current revision
+	NewRevision string `json:"newrevision"` // Identifier of the current revision
+}
+
+// Error returns a descriptive string of the error
+func (e WikiConflictError) Error() string {
+	return fmt.Sprintf("%s: page has been revised as %s", ErrEditConflict, e.NewRevision)
+}
+
+// Is reports whether target is ErrEditConflict
+func (e WikiConflictError) Is(target error) bool {
+	return target == ErrEditConflict
+}
+
+// WikiPage returns the current revision of page in subreddit sub
+func (s *Session) WikiPage(sub string, page string) (*WikiPage, error) {
+	wp := WikiPage{}
+	err := s.getThing(s.url(fmt.Sprintf(apiWikiPage, sub, page)), nil, TypeWikiPage, &wp)
+	if err != nil {
+		return nil, err
+	}
+	return &wp, nil
+}
+
+// WikiPages returns the names of all wiki pages in subreddit sub
+func (s *Session) WikiPages(sub string) ([]string, error) {
+	var pages []string
+	err := s.getThing(s.url(fmt.Sprintf(apiWikiPages, sub)), nil, TypeWikiPageListing, &pages)
+	if err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// EditWikiPage replaces the content of page in subreddit sub with the raw
+// markdown text t. If previous is set to a revision identifier and the page
+// has been revised since, the edit is rejected with a WikiConflictError.
+func (s *Session) EditWikiPage(sub string, page string, t string, reason string, previous string) error {
+	v := url.Values{}
+	v.Set("page", page)
+	v.Set("content", t)
+	if len(reason) > 0 {
+		v.Set("reason", reason)
+	}
+	if len(previous) > 0 {
+		v.Set("previous", previous)
+	}
+
+	resp, err := s.post(s.url(fmt.Sprintf(apiWikiEdit, sub)), v)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		conflict := WikiConflictError{}
+		err = json.NewDecoder(resp.Body).Decode(&conflict)
+		if err != nil {
+			return err
+		}
+		return conflict
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp)
+	}
+	return nil
+}
+
+// WikiRevisions returns the paginated revision history of page in subreddit
+// sub wrapped in a Page type. An empty page name returns the history of all
+// pages in the subreddit.
+func (s *Session) WikiRevisions(sub string, page string) *Page {
+	p := Page{}
+	p.s = s
+	if len(page) == 0 {
+		p.url = s.url(fmt.Sprintf(apiWikiRevisionsAll, sub))
+	} else {
+		p.url = s.url(fmt.Sprintf(apiWikiRevisions, sub, page))
+	}
+	return &p
+}
+
+// RevertWiki reverts page in subreddit sub to revision r
+func (s *Session) RevertWiki(sub string, page string, r string) error {
+	v := url.Values{}
+	v.Set("page", page)
+	v.Set("revision", r)
+
+	return s.call("POST", s.url(fmt.Sprintf(apiWikiRevert, sub)), v, nil)
+}
+
+// WikiPageSettings returns the settings of page in subreddit sub
+func (s *Session) WikiPageSettings(sub string, page string) (*WikiSettings, error) {
+	ws := WikiSettings{}
+	err := s.getThing(s.url(fmt.Sprintf(apiWikiSettings, sub, page)), nil, TypeWikiPageSettings, &ws)
+	if err != nil {
+		return nil, err
+	}
+	return &ws, nil
+}
+
+// SetWikiPageSettings updates the permission level and listing of page in
+// subreddit sub. Editors are managed with AddWikiEditor and RemoveWikiEditor.
+func (s *Session) SetWikiPageSettings(sub string, page string, permlevel int, listed bool) (*WikiSettings, error) {
+	v := url.Values{}
+	v.Set("permlevel", strconv.Itoa(permlevel))
+	v.Set("listed", strconv.FormatBool(listed))
+
+	thing := Thing{}
+	err := s.call("POST", s.url(fmt.Sprintf(apiWikiSettings, sub, page)), v, &thing)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := WikiSettings{}
+	err = json.Unmarshal(thing.Data, &ws)
+	if err != nil {
+		return nil, err
+	}
+	return &ws, nil
+}
+
+// AddWikiEditor allows user u to edit page in subreddit sub
+func (s *Session) AddWikiEditor(sub string, page string, u string) error {
+	return s.wikiEditor(sub, page, u, "add")
+}
+
+// RemoveWikiEditor revokes the permission of user u to edit page in subreddit sub
+func (s *Session) RemoveWikiEditor(sub string, page string, u string) error {
+	return s.wikiEditor(sub, page, u, "del")
+}
+
+func (s *Session) wikiEditor(sub string, page string, u string, act string) error {
+	v := url.Values{}
+	v.Set("page", page)
+	v.Set("username", u)
+
+	return s.call("POST", s.url(fmt.Sprintf(apiWikiEditor, sub, act)), v, nil)
+}
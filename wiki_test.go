@@ -0,0 +1,81 @@
+package rego
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWikiPage(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/r/test/wiki/config.json" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"kind": "wikipage", "data": {"content_md": "key: value", "may_revise": true,
+			"revision_date": 1420070400, "revision_id": "ab-cd",
+			"revision_by": {"kind": "t2", "data": {"name": "mod"}}}}`)
+	}))
+	defer ts.Close()
+
+	page, err := s.WikiPage("test", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Content != "key: value" || page.RevisionID != "ab-cd" || page.RevisionAuthor != "mod" {
+		t.Errorf("Got %+v", page)
+	}
+	if !page.RevisionDate.Equal(time.Unix(1420070400, 0)) {
+		t.Errorf("Got revision date %s", page.RevisionDate)
+	}
+}
+
+func TestEditWikiPageConflict(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("previous") != "old" {
+			t.Errorf("Got previous %q, wanted old", r.FormValue("previous"))
+		}
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"reason": "EDIT_CONFLICT", "newcontent": "theirs", "newrevision": "new", "diffcontent": "<ins>x</ins>"}`)
+	}))
+	defer ts.Close()
+
+	err := s.EditWikiPage("test", "config", "mine", "", "old")
+	conflict, ok := err.(WikiConflictError)
+	if !ok {
+		t.Fatalf("Got %v, wanted WikiConflictError", err)
+	}
+	if conflict.NewRevision != "new" || conflict.NewContent != "theirs" {
+		t.Errorf("Got %+v", conflict)
+	}
+}
+
+func TestWikiRevisions(t *testing.T) {
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("after") == "" {
+			fmt.Fprint(w, `{"kind": "Listing", "data": {"after": "WikiRevision_r2", "before": null, "children": [
+				{"id": "r1", "page": "config", "timestamp": 1420070400.0, "reason": "first",
+				 "author": {"kind": "t2", "data": {"name": "mod"}}},
+				{"id": "r2", "page": "config", "timestamp": 1420070300.0, "reason": null, "author": null}]}}`)
+			return
+		}
+		if r.FormValue("after") != "WikiRevision_r2" {
+			t.Errorf("Got after %q", r.FormValue("after"))
+		}
+		fmt.Fprint(w, `{"kind": "Listing", "data": {"after": null, "before": null, "children": []}}`)
+	}))
+	defer ts.Close()
+
+	page := s.WikiRevisions("test", "config")
+	list, err := page.Previous()
+	if err != nil {
+		t.Fatal(err)
+	}
+	revs := list.WikiRevisions()
+	if len(revs) != 2 || revs[0].Author != "mod" || revs[0].Reason != "first" || revs[1].ID != "r2" {
+		t.Errorf("Got %+v", revs)
+	}
+	if _, err = page.Previous(); err != nil {
+		t.Error(err)
+	}
+}
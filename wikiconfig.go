@@ -0,0 +1,175 @@
+package rego
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoConfigNew = errors.New("wiki config has no New func")
+)
+
+// ConfigEventType describes the outcome of a configuration check
+type ConfigEventType int
+
+// Configuration event types
+const (
+	ConfigLoaded  ConfigEventType = iota // Configuration was loaded for the first time
+	ConfigChanged                        // A new revision was loaded and replaces the current configuration
+	ConfigInvalid                        // A new revision failed to decode or validate, current configuration kept
+	ConfigError                          // The wiki page could not be fetched
+)
+
+// WikiConfig describes a configuration document stored on a subreddit wiki page
+type WikiConfig struct {
+	Decode   func([]byte, interface{}) error // Document decoder, defaults to json.Unmarshal. yaml.Unmarshal is a drop-in.
+	New      func() interface{}              // Returns a pointer to a new zero value configuration
+	Page     string                          // Wiki page name
+	Sub      string                          // Subreddit name
+	Validate func(interface{}) error         // Optional validation of a decoded configuration
+}
+
+// ConfigEvent is emitted by ConfigWatcher when a configuration check
+// results in a new revision being seen or a failure.
+type ConfigEvent struct {
+	Author   string          // Author of the revision
+	Config   interface{}     // Last good configuration, nil if none has been loaded
+	Err      error           // Decode, validation or fetch error
+	Previous interface{}     // Configuration replaced by a ConfigChanged event
+	Revision string          // Wiki page revision identifier
+	Type     ConfigEventType // Type of event
+}
+
+// ConfigWatcher loads a configuration from a wiki page and tracks its
+// revisions. The last good configuration is kept when a new revision
+// fails to decode or validate.
+//
+// A ConfigWatcher is safe for concurrent use.
+type ConfigWatcher struct {
+	s        *Session
+	c        WikiConfig
+	check    sync.Mutex // Serializes checks, so that an older revision never replaces a newer one
+	lock     sync.Mutex
+	config   interface{} // Last good configuration
+	revision string      // Last seen revision, good or bad
+}
+
+// WatchWikiConfig returns a ConfigWatcher for the configuration described by c,
+// or ErrNoConfigNew if c.New is nil. No request is made until ConfigWatcher.Load,
+// Check or Watch is called.
+func (s *Session) WatchWikiConfig(c WikiConfig) (*ConfigWatcher, error) {
+	if c.New == nil {
+		return nil, ErrNoConfigNew
+	}
+	if c.Decode == nil {
+		c.Decode = json.Unmarshal
+	}
+	return &ConfigWatcher{s: s, c: c}, nil
+}
+
+// Config returns the last good configuration, nil if none has been loaded
+func (w *ConfigWatcher) Config() interface{} {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.config
+}
+
+// Revision returns the last seen wiki page revision identifier
+func (w *ConfigWatcher) Revision() string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.revision
+}
+
+// Load checks the wiki page and returns the error, if any, of loading it
+func (w *ConfigWatcher) Load() error {
+	ev := w.Check()
+	if ev == nil {
+		return nil
+	}
+	return ev.Err
+}
+
+// Check fetches the wiki page and loads it if the revision differs from
+// the last seen one. A nil event is returned if the revision is unchanged.
+// Concurrent checks are serialized.
+func (w *ConfigWatcher) Check() *ConfigEvent {
+	w.check.Lock()
+	defer w.check.Unlock()
+	page, err := w.s.WikiPage(w.c.Sub, w.c.Page)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if err != nil {
+		return &ConfigEvent{Type: ConfigError, Config: w.config, Revision: w.revision, Err: err}
+	}
+	if page.RevisionID == w.revision {
+		return nil
+	}
+	w.revision = page.RevisionID
+
+	ev := ConfigEvent{Author: page.RevisionAuthor, Config: w.config, Revision: page.RevisionID}
+	config, err := w.decode(page.Content)
+	if err != nil {
+		ev.Type = ConfigInvalid
+		ev.Err = err
+		return &ev
+	}
+
+	ev.Type = ConfigChanged
+	if w.config == nil {
+		ev.Type = ConfigLoaded
+	}
+	ev.Previous = w.config
+	ev.Config = config
+	w.config = config
+	return &ev
+}
+
+// Watch checks the wiki page immediately and then once every interval,
+// emitting an event for each check that is not a no-op. The returned
+// channel is closed when stop is closed.
+func (w *ConfigWatcher) Watch(interval time.Duration, stop <-chan struct{}) <-chan ConfigEvent {
+	events := make(chan ConfigEvent)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if ev := w.Check(); ev != nil {
+				select {
+				case events <- *ev:
+				case <-stop:
+					return
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// decode decodes and validates the wiki page content. Reddit returns
+// the page markdown HTML escaped, it is unescaped before decoding.
+func (w *ConfigWatcher) decode(content string) (interface{}, error) {
+	config := w.c.New()
+	err := w.c.Decode([]byte(html.UnescapeString(content)), config)
+	if err != nil {
+		return nil, err
+	}
+	if w.c.Validate != nil {
+		err = w.c.Validate(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,122 @@
+package rego
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Greeting string `json:"greeting"`
+	Limit    int    `json:"limit"`
+}
+
+func TestConfigWatcher(t *testing.T) {
+	var lock sync.Mutex
+	revision, content := "r1", `{"greeting": "hi &amp; welcome", "limit": 1}`
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		data, _ := json.Marshal(map[string]string{"content_md": content, "revision_id": revision})
+		json.NewEncoder(w).Encode(Thing{Kind: TypeWikiPage, Data: data})
+	}))
+	defer ts.Close()
+
+	errLimit := errors.New("limit must be positive")
+	watcher, err := s.WatchWikiConfig(WikiConfig{
+		Sub:  "test",
+		Page: "config",
+		New:  func() interface{} { return &testConfig{} },
+		Validate: func(c interface{}) error {
+			if c.(*testConfig).Limit <= 0 {
+				return errLimit
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := func(r string, c string) {
+		lock.Lock()
+		revision, content = r, c
+		lock.Unlock()
+	}
+
+	var tests = []struct {
+		revision string
+		content  string
+		event    ConfigEventType
+		err      error
+		limit    int
+	}{
+		{"r1", `{"greeting": "hi &amp; welcome", "limit": 1}`, ConfigLoaded, nil, 1},
+		{"r2", `{"limit": 0}`, ConfigInvalid, errLimit, 1},
+		{"r3", `{"limit": `, ConfigInvalid, nil, 1},
+		{"r4", `{"greeting": "hello", "limit": 4}`, ConfigChanged, nil, 4},
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	events := watcher.Watch(time.Millisecond, stop)
+	for _, test := range tests {
+		update(test.revision, test.content)
+		ev := <-events
+		if ev.Type != test.event || ev.Revision != test.revision {
+			t.Errorf("Got event %d for %s, wanted %d for %s", ev.Type, ev.Revision, test.event, test.revision)
+		}
+		if test.err != nil && ev.Err != test.err {
+			t.Errorf("Got error %v, wanted %v", ev.Err, test.err)
+		}
+		if c := ev.Config.(*testConfig); c.Limit != test.limit {
+			t.Errorf("Got limit %d, wanted %d", c.Limit, test.limit)
+		}
+	}
+	if c := watcher.Config().(*testConfig); c.Greeting != "hello" {
+		t.Errorf("Got greeting %q", c.Greeting)
+	}
+}
+
+func TestConfigWatcherCheck(t *testing.T) {
+	var lock sync.Mutex
+	served := 0
+	s, ts := newTestSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		served++
+		revision := strconv.Itoa(served)
+		lock.Unlock()
+		data, _ := json.Marshal(map[string]string{"content_md": "{}", "revision_id": revision})
+		json.NewEncoder(w).Encode(Thing{Kind: TypeWikiPage, Data: data})
+	}))
+	defer ts.Close()
+
+	_, err := s.WatchWikiConfig(WikiConfig{Sub: "test", Page: "config"})
+	if err != ErrNoConfigNew {
+		t.Errorf("Got %v, wanted ErrNoConfigNew", err)
+	}
+
+	watcher, err := s.WatchWikiConfig(WikiConfig{
+		Sub:  "test",
+		Page: "config",
+		New:  func() interface{} { return &testConfig{} },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watcher.Check()
+		}()
+	}
+	wg.Wait()
+	if r := watcher.Revision(); r != "8" {
+		t.Errorf("Got revision %s, wanted the last fetched 8", r)
+	}
+}